@@ -0,0 +1,101 @@
+package genaistructbuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultBatchConcurrency is the number of generators BuildBatch runs at once
+// when BatchOptions.Concurrency is zero.
+const DefaultBatchConcurrency = 4
+
+// ErrSkipped is the error of batch items that never ran because the batch was
+// aborted, either by FailFast or by the caller's context.
+var ErrSkipped = errors.New("batch item skipped")
+
+// BatchOptions configures BuildBatch.
+type BatchOptions struct {
+	// Concurrency bounds how many generators run at once.
+	Concurrency int
+	// FailFast cancels the outstanding items as soon as one item fails.
+	// Otherwise every item runs and reports its own error.
+	FailFast bool
+	// OnProgress is called after each item finishes, one call at a time.
+	OnProgress func(BatchProgress)
+}
+
+// BatchProgress reports a finished batch item.
+type BatchProgress struct {
+	Index     int
+	Err       error
+	Completed int
+	Failed    int
+	Total     int
+}
+
+// BuildBatch runs every generator against model with bounded concurrency and
+// returns one result per generator, in the same order. Item errors are
+// reported in Result.Err; BuildBatch itself never fails.
+func (b *GenAiStructBuilder[T]) BuildBatch(ctx context.Context, generators []Generator[T], model string, opts BatchOptions) []Result[T] {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	concurrency = min(concurrency, len(generators))
+	batchCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	results := make([]Result[T], len(generators))
+	indexes := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		progress = BatchProgress{Total: len(generators)}
+		start    = time.Now()
+	)
+	finish := func(index int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		progress.Index = index
+		progress.Err = err
+		progress.Completed++
+		if err != nil {
+			progress.Failed++
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(progress)
+		}
+	}
+
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				if batchCtx.Err() != nil {
+					results[index].Err = fmt.Errorf("%w: %w", ErrSkipped, context.Cause(batchCtx))
+					finish(index, results[index].Err)
+					continue
+				}
+				result, err := b.BuildWithResult(batchCtx, generators[index], model)
+				results[index] = result
+				if err != nil && opts.FailFast {
+					cancel(fmt.Errorf("batch item %d failed: %w", index, err))
+				}
+				finish(index, err)
+			}
+		}()
+	}
+	for index := range generators {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	b.config.logger.InfoContext(ctx, "batch completed",
+		"model", model, "items", len(generators), "failed", progress.Failed, "duration", time.Since(start))
+	return results
+}
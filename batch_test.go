@@ -0,0 +1,121 @@
+package genaistructbuilder_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	genai "google.golang.org/genai"
+)
+
+var batchItemPattern = regexp.MustCompile(`item-\d+`)
+
+// newEchoMock answers with the item-N marker of the request as job title
+// after delay and fails for the items in failing.
+func newEchoMock(delay time.Duration, failing map[string]bool) *RecordingMock {
+	mock := &RecordingMock{Delay: delayed(delay)}
+	item := func(n int) string {
+		return batchItemPattern.FindString(contentsText(mock.Contents()[n-1]))
+	}
+	mock.Err = func(n int) error {
+		if name := item(n); failing[name] {
+			return genai.APIError{Code: 400, Message: "bad request for " + name}
+		}
+		return nil
+	}
+	mock.Respond = func(n int, _ *genai.GenerateContentResponse) *genai.GenerateContentResponse {
+		return candidateResponse(fmt.Sprintf(`{"job_title": %q}`, item(n)), genai.FinishReasonStop)
+	}
+	return mock
+}
+
+func newBatchGenerators(n int) []genaistructbuilder.Generator[JobSearchOutput] {
+	generators := make([]genaistructbuilder.Generator[JobSearchOutput], n)
+	for i := range generators {
+		generators[i] = &generator.RelationGenerator[JobSearchOutput]{
+			RelationEntity:     "Profile Search",
+			RelationRecordJSON: fmt.Sprintf(`{"offer": "item-%d"}`, i),
+		}
+	}
+	return generators
+}
+
+func TestGenAiStructBuilder_BuildBatch_OrderedAndBounded(t *testing.T) {
+	mock := newEchoMock(5*time.Millisecond, map[string]bool{"item-3": true})
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+
+	var progress []genaistructbuilder.BatchProgress
+	results := builder.BuildBatch(context.Background(), newBatchGenerators(12), "gemini-test-mock", genaistructbuilder.BatchOptions{
+		Concurrency: 3,
+		OnProgress:  func(p genaistructbuilder.BatchProgress) { progress = append(progress, p) },
+	})
+
+	if len(results) != 12 {
+		t.Fatalf("❌ expected 12 results, got %d", len(results))
+	}
+	for i, result := range results {
+		if i == 3 {
+			var providerErr *genaistructbuilder.ProviderError
+			if !errors.As(result.Err, &providerErr) {
+				t.Errorf("❌ expected item 3 to fail with a ProviderError, got %v", result.Err)
+			}
+			continue
+		}
+		if result.Err != nil || result.Value.JobTitle != fmt.Sprintf("item-%d", i) || result.Attempts != 1 {
+			t.Errorf("❌ item %d: unexpected result %+v", i, result)
+		}
+	}
+	if mock.MaxInFlight() > 3 {
+		t.Errorf("❌ expected at most 3 calls in flight, got %d", mock.MaxInFlight())
+	}
+	last := progress[len(progress)-1]
+	if len(progress) != 12 || last.Completed != 12 || last.Failed != 1 || last.Total != 12 {
+		t.Errorf("❌ unexpected progress: %d callbacks, last %+v", len(progress), last)
+	}
+}
+
+func TestGenAiStructBuilder_BuildBatch_FailFast(t *testing.T) {
+	mock := newEchoMock(20*time.Millisecond, map[string]bool{"item-0": true})
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+
+	results := builder.BuildBatch(context.Background(), newBatchGenerators(20), "gemini-test-mock", genaistructbuilder.BatchOptions{Concurrency: 2, FailFast: true})
+
+	skipped := 0
+	for i, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		if errors.Is(result.Err, genaistructbuilder.ErrSkipped) {
+			skipped++
+			if i < 2 {
+				t.Errorf("❌ item %d started before the failure and must not be skipped", i)
+			}
+		}
+	}
+	if results[0].Err == nil || skipped < 15 {
+		t.Errorf("❌ expected the failure to skip the outstanding items, got %d skipped", skipped)
+	}
+}
+
+func TestGenAiStructBuilder_BuildBatch_ContextCancellation(t *testing.T) {
+	mock := newEchoMock(time.Second, nil)
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	results := builder.BuildBatch(ctx, newBatchGenerators(10), "gemini-test-mock", genaistructbuilder.BatchOptions{Concurrency: 2})
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("❌ expected cancellation to stop the batch promptly, took %v", elapsed)
+	}
+	for i, result := range results {
+		if !errors.Is(result.Err, genaistructbuilder.ErrDeadlineExceeded) && !errors.Is(result.Err, genaistructbuilder.ErrSkipped) {
+			t.Errorf("❌ item %d: expected a deadline or skipped error, got %v", i, result.Err)
+		}
+	}
+}
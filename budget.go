@@ -0,0 +1,85 @@
+package genaistructbuilder
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"google.golang.org/genai"
+)
+
+// TokenCounter counts the input tokens model would bill for contents.
+type TokenCounter func(ctx context.Context, model string, contents []*genai.Content) (int, error)
+
+// CountTokensFunc matches the signature of client.Models.CountTokens.
+type CountTokensFunc func(ctx context.Context, model string, contents []*genai.Content, config *genai.CountTokensConfig) (*genai.CountTokensResponse, error)
+
+// CountTokensCounter returns a TokenCounter backed by the CountTokens API.
+func CountTokensCounter(countTokens CountTokensFunc) TokenCounter {
+	return func(ctx context.Context, model string, contents []*genai.Content) (int, error) {
+		resp, err := countTokens(ctx, model, contents, nil)
+		if err != nil {
+			return 0, fmt.Errorf("counting tokens failed: %w", err)
+		}
+		return int(resp.TotalTokens), nil
+	}
+}
+
+// mediaPartTokens is what Gemini bills for a single image.
+const mediaPartTokens = 258
+
+// EstimateTokens is an offline TokenCounter that assumes about four characters
+// per token and a fixed cost per inline or file part.
+func EstimateTokens(ctx context.Context, model string, contents []*genai.Content) (int, error) {
+	total := 0
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+		for _, part := range content.Parts {
+			if part == nil {
+				continue
+			}
+			total += (utf8.RuneCountInString(part.Text) + 3) / 4
+			if part.InlineData != nil || part.FileData != nil {
+				total += mediaPartTokens
+			}
+		}
+	}
+	return total, nil
+}
+
+// TokenBudget caps the input tokens of a request by dropping few-shot examples.
+// Examples are packed in priority order, which is the ranking of the
+// ExampleSelector when one is set and the generator's example order otherwise,
+// until the next example would exceed MaxInputTokens; it and every example
+// after it are dropped.
+type TokenBudget struct {
+	// MaxInputTokens is the budget for instructions, examples and input.
+	// Zero disables packing.
+	MaxInputTokens int
+	// Counter counts tokens. Nil uses EstimateTokens.
+	Counter TokenCounter
+	// OnReport receives the outcome of every packed request.
+	OnReport func(BudgetReport)
+}
+
+// BudgetReport describes how examples were packed into a TokenBudget.
+type BudgetReport struct {
+	Model          string
+	MaxInputTokens int
+	// InputTokens is the estimated size of the request that was sent.
+	InputTokens int
+	// Kept is the number of examples sent.
+	Kept    int
+	Dropped []DroppedExample
+}
+
+// DroppedExample is an example left out to stay within the budget.
+type DroppedExample struct {
+	Category string
+	Input    string
+	// Tokens is what the example would have added to the request. Examples
+	// after the first one that did not fit are not measured and report zero.
+	Tokens int
+}
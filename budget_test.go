@@ -0,0 +1,146 @@
+package genaistructbuilder_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	genai "google.golang.org/genai"
+)
+
+// turnCounter bills ten tokens per content, so every example turn pair costs 20.
+func turnCounter(ctx context.Context, model string, contents []*genai.Content) (int, error) {
+	return 10 * len(contents), nil
+}
+
+func newBudgetPromptGenerator() *generator.PromptGenerator[JobSearchOutput] {
+	return &generator.PromptGenerator[JobSearchOutput]{
+		Prompt:        "Find Go seniors in Egypt",
+		ExampleFormat: genaistructbuilder.ExampleFormatTurns,
+		Examples: []genaistructbuilder.PromptExample[JobSearchOutput]{
+			{Prompt: "first example", Response: JobSearchOutput{JobTitle: "First"}},
+			{Prompt: "second example", Response: JobSearchOutput{JobTitle: "Second"}},
+			{Prompt: "third example", Response: JobSearchOutput{JobTitle: "Third"}},
+		},
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	contents := []*genai.Content{
+		genai.NewContentFromText(strings.Repeat("a", 40), genai.RoleUser),
+		genai.NewContentFromBytes([]byte("png"), "image/png", genai.RoleUser),
+	}
+	got, _ := genaistructbuilder.EstimateTokens(context.Background(), "gemini-test-mock", contents)
+	if got != 10+258 {
+		t.Errorf("❌ expected 268 estimated tokens, got %d", got)
+	}
+}
+
+func TestCountTokensCounter(t *testing.T) {
+	var gotModel string
+	counter := genaistructbuilder.CountTokensCounter(func(ctx context.Context, model string, contents []*genai.Content, config *genai.CountTokensConfig) (*genai.CountTokensResponse, error) {
+		gotModel = model
+		return &genai.CountTokensResponse{TotalTokens: int32(7 * len(contents))}, nil
+	})
+	got, err := counter(context.Background(), "gemini-test-mock", []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)})
+	if err != nil || got != 7 || gotModel != "gemini-test-mock" {
+		t.Errorf("❌ expected 7 tokens for gemini-test-mock, got %d (model %q, err %v)", got, gotModel, err)
+	}
+}
+
+func TestGenAiStructBuilder_TokenBudget_DropsLowestPriorityExamples(t *testing.T) {
+	var report genaistructbuilder.BudgetReport
+	mock := &RecordingMock{Outputs: []string{validMockJSON}}
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+		mock.GenerateContent,
+		genaistructbuilder.WithTokenBudget(genaistructbuilder.TokenBudget{
+			MaxInputTokens: 50,
+			Counter:        turnCounter,
+			OnReport:       func(r genaistructbuilder.BudgetReport) { report = r },
+		}),
+	)
+
+	var output JobSearchOutput
+	if err := builder.Build(newBudgetPromptGenerator(), "gemini-test-mock", &output); err != nil {
+		t.Fatalf("❌ Build failed: %v", err)
+	}
+	sent := contentsText(mock.Contents()[0])
+	if !strings.Contains(sent, "first example") || !strings.Contains(sent, "second example") || strings.Contains(sent, "third example") {
+		t.Errorf("❌ expected only the first two examples to be sent, got:\n%s", sent)
+	}
+	if report.Kept != 2 || report.InputTokens != 50 || len(report.Dropped) != 1 ||
+		report.Dropped[0].Input != "third example" || report.Dropped[0].Tokens != 20 {
+		t.Errorf("❌ unexpected budget report: %+v", report)
+	}
+}
+
+func TestGenAiStructBuilder_TokenBudget_FollowsSelectorRanking(t *testing.T) {
+	mock := &RecordingMock{Outputs: []string{validMockJSON}}
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+		mock.GenerateContent,
+		genaistructbuilder.WithTokenBudget(genaistructbuilder.TokenBudget{MaxInputTokens: 30, Counter: turnCounter}),
+	)
+	g := newBudgetPromptGenerator()
+	g.ExampleSelector = genaistructbuilder.ExampleSelectorFunc(func(ctx context.Context, input string, candidates []genaistructbuilder.ExampleCandidate) ([]int, error) {
+		return []int{2, 0}, nil
+	})
+
+	var output JobSearchOutput
+	if err := builder.Build(g, "gemini-test-mock", &output); err != nil {
+		t.Fatalf("❌ Build failed: %v", err)
+	}
+	sent := contentsText(mock.Contents()[0])
+	if !strings.Contains(sent, "third example") || strings.Contains(sent, "first example") {
+		t.Errorf("❌ expected the top-ranked example to win the budget, got:\n%s", sent)
+	}
+}
+
+func TestGenAiStructBuilder_TokenBudget_InputOverBudget(t *testing.T) {
+	mock := &RecordingMock{Outputs: []string{validMockJSON}}
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+		mock.GenerateContent,
+		genaistructbuilder.WithTokenBudget(genaistructbuilder.TokenBudget{MaxInputTokens: 5, Counter: turnCounter}),
+	)
+	var output JobSearchOutput
+	err := builder.Build(newBudgetPromptGenerator(), "gemini-test-mock", &output)
+	var budgetErr *genaistructbuilder.BudgetError
+	if !errors.As(err, &budgetErr) || !errors.Is(err, genaistructbuilder.ErrOverBudget) {
+		t.Fatalf("❌ expected a BudgetError when the input alone exceeds the budget, got %v", err)
+	}
+	if budgetErr.InputTokens != 10 || budgetErr.MaxInputTokens != 5 {
+		t.Errorf("❌ unexpected budget error: %+v", budgetErr)
+	}
+	if mock.Calls() != 0 {
+		t.Error("❌ expected no model call when the input exceeds the budget")
+	}
+}
+
+func TestGenAiStructBuilder_TokenBudget_CountsRequestOnce(t *testing.T) {
+	var calls, requestCalls int
+	counter := func(ctx context.Context, model string, contents []*genai.Content) (int, error) {
+		calls++
+		if strings.Contains(contentsText(contents), "Find Go seniors in Egypt") {
+			requestCalls++
+		}
+		return turnCounter(ctx, model, contents)
+	}
+	for _, format := range []genaistructbuilder.ExampleFormat{genaistructbuilder.ExampleFormatTurns, genaistructbuilder.ExampleFormatFlat} {
+		calls, requestCalls = 0, 0
+		builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+			MockGenerateContentFunc,
+			genaistructbuilder.WithTokenBudget(genaistructbuilder.TokenBudget{MaxInputTokens: 1000, Counter: counter}),
+		)
+		g := newBudgetPromptGenerator()
+		g.ExampleFormat = format
+		var output JobSearchOutput
+		if err := builder.Build(g, "gemini-test-mock", &output); err != nil {
+			t.Fatalf("❌ Build failed: %v", err)
+		}
+		if calls != 4 || requestCalls != 1 {
+			t.Errorf("❌ format %d: expected the request counted once and each example alone, got %d calls, %d with the request", format, calls, requestCalls)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package genaistructbuilder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/darwishdev/genaistructbuilder"
+)
+
+func TestGenAiStructBuilder_BuildContext_AllRealGenerators(t *testing.T) {
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput]((&RecordingMock{Delay: delayed(200 * time.Millisecond)}).GenerateContent)
+	testModel := "gemini-test-mock"
+
+	for _, tc := range newGeneratorCases(t) {
+		t.Run(tc.Name+"/deadline", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			var output JobSearchOutput
+			start := time.Now()
+			err := builder.BuildContext(ctx, tc.Generator, testModel, &output)
+			if !errors.Is(err, genaistructbuilder.ErrDeadlineExceeded) {
+				t.Fatalf("❌ %s: expected ErrDeadlineExceeded, got %v", tc.Name, err)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("❌ %s: expected the context.DeadlineExceeded cause to be kept, got %v", tc.Name, err)
+			}
+			if errors.Is(err, genaistructbuilder.ErrCanceled) {
+				t.Errorf("❌ %s: deadline must not be reported as cancellation: %v", tc.Name, err)
+			}
+			if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+				t.Errorf("❌ %s: BuildContext did not return promptly, took %s", tc.Name, elapsed)
+			}
+		})
+
+		t.Run(tc.Name+"/canceled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(10*time.Millisecond, cancel)
+
+			var output JobSearchOutput
+			err := builder.BuildContext(ctx, tc.Generator, testModel, &output)
+			if !errors.Is(err, genaistructbuilder.ErrCanceled) {
+				t.Fatalf("❌ %s: expected ErrCanceled, got %v", tc.Name, err)
+			}
+			if errors.Is(err, genaistructbuilder.ErrDeadlineExceeded) {
+				t.Errorf("❌ %s: cancellation must not be reported as deadline: %v", tc.Name, err)
+			}
+		})
+
+		t.Run(tc.Name+"/within_deadline", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			var output JobSearchOutput
+			if err := builder.BuildContext(ctx, tc.Generator, testModel, &output); err != nil {
+				t.Fatalf("❌ %s: BuildContext failed: %v", tc.Name, err)
+			}
+			CheckOutput(t, output, tc.Name)
+		})
+	}
+}
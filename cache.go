@@ -0,0 +1,285 @@
+package genaistructbuilder
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/darwishdev/genaistructbuilder/internal/buildctx"
+	"google.golang.org/genai"
+)
+
+// Cache stores serialized model responses under a request key.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// RequestKey hashes model, contents and config into a cache key. The request is
+// encoded as JSON with object keys sorted, so equal requests always produce the
+// same key. HTTP options do not take part in the key.
+func RequestKey(model string, contents []*genai.Content, config *genai.GenerateContentConfig) (string, error) {
+	if config != nil {
+		copied := *config
+		copied.HTTPOptions = nil
+		config = &copied
+	}
+	encoded, err := json.Marshal(struct {
+		Model    string                       `json:"model"`
+		Contents []*genai.Content             `json:"contents"`
+		Config   *genai.GenerateContentConfig `json:"config"`
+	}{model, contents, config})
+	if err != nil {
+		return "", fmt.Errorf("hashing request failed: %w", err)
+	}
+	// Round-trip through generic values so every object, including those with
+	// custom marshalers, is written with sorted keys.
+	var canonical any
+	if err := json.Unmarshal(encoded, &canonical); err != nil {
+		return "", fmt.Errorf("hashing request failed: %w", err)
+	}
+	encoded, err = json.Marshal(canonical)
+	if err != nil {
+		return "", fmt.Errorf("hashing request failed: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CacheOptions tunes a ResponseCache.
+type CacheOptions struct {
+	// Force caches requests whose temperature is above 0 or unset, which are
+	// otherwise passed through because their responses are not reproducible.
+	Force bool
+}
+
+// CacheStats counts how requests went through a ResponseCache.
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+	// Skipped counts requests that bypassed the cache because of their temperature.
+	Skipped int64
+	// Errors counts cache reads and writes that failed; the call still goes through.
+	Errors int64
+}
+
+// ResponseCache is a middleware that answers repeated requests from a Cache.
+// Only responses that finished normally are stored, and inside a build only
+// once their output decoded and passed validation.
+type ResponseCache struct {
+	cache Cache
+	opts  CacheOptions
+
+	hits, misses, skipped, failed atomic.Int64
+}
+
+// NewResponseCache returns a ResponseCache backed by cache.
+func NewResponseCache(cache Cache, opts CacheOptions) *ResponseCache {
+	return &ResponseCache{cache: cache, opts: opts}
+}
+
+// Stats returns the counters collected so far.
+func (c *ResponseCache) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Skipped: c.skipped.Load(), Errors: c.failed.Load()}
+}
+
+// Middleware returns the caching middleware, for WithMiddleware or Chain.
+func (c *ResponseCache) Middleware() Middleware {
+	return func(next GenerateContentFunc) GenerateContentFunc {
+		return func(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+			if !c.opts.Force && (config == nil || config.Temperature == nil || *config.Temperature > 0) {
+				c.skipped.Add(1)
+				return next(ctx, model, contents, config)
+			}
+			logger := loggerFromContext(ctx)
+			key, err := RequestKey(model, contents, config)
+			if err != nil {
+				c.failed.Add(1)
+				logger.WarnContext(ctx, "response cache key failed", "model", model, "error", err)
+				return next(ctx, model, contents, config)
+			}
+			if cached, ok, err := c.cache.Get(ctx, key); err != nil {
+				c.failed.Add(1)
+				logger.WarnContext(ctx, "response cache read failed", "model", model, "error", err)
+			} else if ok {
+				var resp genai.GenerateContentResponse
+				if err := json.Unmarshal(cached, &resp); err == nil {
+					c.hits.Add(1)
+					logger.DebugContext(ctx, "response cache hit", "model", model, "key", key)
+					buildctx.CollectorFrom(ctx).RecordCacheHit(&resp)
+					return &resp, nil
+				}
+				c.failed.Add(1)
+			}
+			c.misses.Add(1)
+			resp, err := next(ctx, model, contents, config)
+			if err != nil || !cacheable(resp) {
+				return resp, err
+			}
+			store := func() {
+				encoded, err := json.Marshal(resp)
+				if err == nil {
+					err = c.cache.Set(ctx, key, encoded)
+				}
+				if err != nil {
+					c.failed.Add(1)
+					logger.WarnContext(ctx, "response cache write failed", "model", model, "error", err)
+				}
+			}
+			if !buildctx.PendingFrom(ctx).Defer(store) {
+				store()
+			}
+			return resp, nil
+		}
+	}
+}
+
+func cacheable(resp *genai.GenerateContentResponse) bool {
+	if resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0] == nil {
+		return false
+	}
+	switch resp.Candidates[0].FinishReason {
+	case "", genai.FinishReasonUnspecified, genai.FinishReasonStop:
+		return true
+	}
+	return false
+}
+
+// MemoryCache is an in-memory Cache that evicts the least recently used entry
+// beyond its capacity and expires entries after a TTL.
+type MemoryCache struct {
+	capacity int
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns a MemoryCache holding up to capacity entries for ttl.
+// A capacity or ttl of zero means no limit.
+func NewMemoryCache(capacity int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{capacity: capacity, ttl: ttl, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &memoryEntry{key: key, value: value}
+	if c.ttl > 0 {
+		entry.expires = time.Now().Add(c.ttl)
+	}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// Len returns the number of entries held, including expired ones not yet evicted.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// DirCache is a Cache storing one file per entry in a directory, so cached
+// responses survive restarts. Entries older than the TTL are treated as missing.
+type DirCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewDirCache returns a DirCache in dir, creating it if needed. A ttl of zero
+// keeps entries forever.
+func NewDirCache(dir string, ttl time.Duration) (*DirCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache directory failed: %w", err)
+	}
+	return &DirCache{dir: dir, ttl: ttl}, nil
+}
+
+func (c *DirCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+func (c *DirCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if c.ttl > 0 && time.Since(info.ModTime()) > c.ttl {
+		_ = os.Remove(path)
+		return nil, false, nil
+	}
+	value, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set writes through a temporary file so readers never see a partial entry.
+func (c *DirCache) Set(ctx context.Context, key string, value []byte) error {
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
@@ -0,0 +1,205 @@
+package genaistructbuilder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	genai "google.golang.org/genai"
+)
+
+func TestRequestKey_Canonical(t *testing.T) {
+	contents := []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)}
+	config := func(labels map[string]string) *genai.GenerateContentConfig {
+		return &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0), Labels: labels}
+	}
+	a, err := genaistructbuilder.RequestKey("gemini-test-mock", contents, config(map[string]string{"a": "1", "b": "2", "c": "3"}))
+	if err != nil {
+		t.Fatalf("❌ RequestKey failed: %v", err)
+	}
+	for range 20 {
+		b, _ := genaistructbuilder.RequestKey("gemini-test-mock", contents, config(map[string]string{"c": "3", "b": "2", "a": "1"}))
+		if a != b {
+			t.Fatal("❌ expected equal requests to hash equally")
+		}
+	}
+	withHTTP := config(map[string]string{"a": "1", "b": "2", "c": "3"})
+	withHTTP.HTTPOptions = &genai.HTTPOptions{Timeout: genai.Ptr(time.Second)}
+	if b, _ := genaistructbuilder.RequestKey("gemini-test-mock", contents, withHTTP); a != b {
+		t.Error("❌ expected HTTP options to be ignored")
+	}
+	if b, _ := genaistructbuilder.RequestKey("gemini-other", contents, config(nil)); a == b {
+		t.Error("❌ expected a different model to change the key")
+	}
+}
+
+func TestGenAiStructBuilder_ResponseCache_AllRealGenerators(t *testing.T) {
+	newCaches := map[string]func(t *testing.T) genaistructbuilder.Cache{
+		"memory": func(t *testing.T) genaistructbuilder.Cache { return genaistructbuilder.NewMemoryCache(10, time.Minute) },
+		"dir": func(t *testing.T) genaistructbuilder.Cache {
+			cache, err := genaistructbuilder.NewDirCache(t.TempDir(), time.Minute)
+			if err != nil {
+				t.Fatalf("❌ NewDirCache failed: %v", err)
+			}
+			return cache
+		},
+	}
+	for cacheName, newCache := range newCaches {
+		for _, tc := range newGeneratorCases(t) {
+			t.Run(cacheName+"/"+tc.Name, func(t *testing.T) {
+				mock := &RecordingMock{}
+				cache := genaistructbuilder.NewResponseCache(newCache(t), genaistructbuilder.CacheOptions{})
+				builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent, genaistructbuilder.WithMiddleware(cache.Middleware()))
+
+				for range 3 {
+					result, err := builder.BuildWithResult(context.Background(), tc.Generator, "gemini-test-mock")
+					if err != nil {
+						t.Fatalf("❌ BuildWithResult failed: %v", err)
+					}
+					if result.Value.JobTitle != "Mock Data Engineer" {
+						t.Errorf("❌ unexpected cached value: %+v", result.Value)
+					}
+				}
+				if mock.Calls() != 1 {
+					t.Errorf("❌ expected one model call, got %d", mock.Calls())
+				}
+				if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 1 || stats.Skipped != 0 || stats.Errors != 0 {
+					t.Errorf("❌ unexpected stats: %+v", stats)
+				}
+			})
+		}
+	}
+}
+
+func TestResponseCache_SkipsNonZeroTemperatureUnlessForced(t *testing.T) {
+	g := &generator.PromptGenerator[JobSearchOutput]{Prompt: "x"}
+	g.Temperature = genai.Ptr[float32](0.7)
+
+	for _, force := range []bool{false, true} {
+		mock := &RecordingMock{}
+		cache := genaistructbuilder.NewResponseCache(genaistructbuilder.NewMemoryCache(0, 0), genaistructbuilder.CacheOptions{Force: force})
+		builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent, genaistructbuilder.WithMiddleware(cache.Middleware()))
+		var output JobSearchOutput
+		for range 2 {
+			if err := builder.Build(g, "gemini-test-mock", &output); err != nil {
+				t.Fatalf("❌ Build failed: %v", err)
+			}
+		}
+		stats := cache.Stats()
+		if force && (mock.Calls() != 1 || stats.Hits != 1) {
+			t.Errorf("❌ forced cache: expected one call and one hit, got %d calls, %+v", mock.Calls(), stats)
+		}
+		if !force && (mock.Calls() != 2 || stats.Skipped != 2) {
+			t.Errorf("❌ expected warm requests to skip the cache, got %d calls, %+v", mock.Calls(), stats)
+		}
+	}
+}
+
+func TestResponseCache_DoesNotStoreUnfinishedResponses(t *testing.T) {
+	memory := genaistructbuilder.NewMemoryCache(0, 0)
+	cache := genaistructbuilder.NewResponseCache(memory, genaistructbuilder.CacheOptions{})
+	fn := cache.Middleware()((&RecordingMock{Respond: respondWith(candidateResponse(`{"skills"`, genai.FinishReasonMaxTokens))}).GenerateContent)
+	config := &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0)}
+	for range 2 {
+		if _, err := fn(context.Background(), "gemini-test-mock", nil, config); err != nil {
+			t.Fatalf("❌ call failed: %v", err)
+		}
+	}
+	if memory.Len() != 0 || cache.Stats().Misses != 2 {
+		t.Errorf("❌ expected truncated responses not to be cached, got %d entries, %+v", memory.Len(), cache.Stats())
+	}
+}
+
+func TestGenAiStructBuilder_ResponseCache_DoesNotStoreRejectedOutput(t *testing.T) {
+	memory := genaistructbuilder.NewMemoryCache(0, 0)
+	cache := genaistructbuilder.NewResponseCache(memory, genaistructbuilder.CacheOptions{})
+	mock := &RecordingMock{Outputs: []string{"not json", validMockJSON}}
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent, genaistructbuilder.WithMiddleware(cache.Middleware()))
+
+	g := &generator.PromptGenerator[JobSearchOutput]{Prompt: "x"}
+	var output JobSearchOutput
+	var decodeErr *genaistructbuilder.DecodeError
+	if err := builder.Build(g, "gemini-test-mock", &output); !errors.As(err, &decodeErr) {
+		t.Fatalf("❌ expected a DecodeError, got %v", err)
+	}
+	if memory.Len() != 0 {
+		t.Fatalf("❌ expected the rejected output not to be cached, got %d entries", memory.Len())
+	}
+	for range 2 {
+		if err := builder.Build(g, "gemini-test-mock", &output); err != nil {
+			t.Fatalf("❌ Build failed: %v", err)
+		}
+	}
+	if stats := cache.Stats(); mock.Calls() != 2 || stats.Hits != 1 || stats.Misses != 2 || memory.Len() != 1 {
+		t.Errorf("❌ expected the valid output to be cached after the rejected one, got %d calls, %+v", mock.Calls(), stats)
+	}
+}
+
+func TestMemoryCache_LRUAndTTL(t *testing.T) {
+	ctx := context.Background()
+	cache := genaistructbuilder.NewMemoryCache(2, 0)
+	cache.Set(ctx, "a", []byte("1"))
+	cache.Set(ctx, "b", []byte("2"))
+	cache.Get(ctx, "a")
+	cache.Set(ctx, "c", []byte("3"))
+	if _, ok, _ := cache.Get(ctx, "b"); ok {
+		t.Error("❌ expected the least recently used entry to be evicted")
+	}
+	if _, ok, _ := cache.Get(ctx, "a"); !ok {
+		t.Error("❌ expected the recently used entry to survive")
+	}
+
+	expiring := genaistructbuilder.NewMemoryCache(0, 10*time.Millisecond)
+	expiring.Set(ctx, "a", []byte("1"))
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, _ := expiring.Get(ctx, "a"); ok {
+		t.Error("❌ expected the entry to expire")
+	}
+}
+
+func TestDirCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cache, _ := genaistructbuilder.NewDirCache(dir, 10*time.Millisecond)
+	if err := cache.Set(ctx, "a", []byte("1")); err != nil {
+		t.Fatalf("❌ Set failed: %v", err)
+	}
+	reopened, _ := genaistructbuilder.NewDirCache(dir, 10*time.Millisecond)
+	if value, ok, _ := reopened.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Error("❌ expected the entry to be read back from disk")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, _ := reopened.Get(ctx, "a"); ok {
+		t.Error("❌ expected the entry to expire")
+	}
+}
+
+func TestGenAiStructBuilder_ResponseCache_RecordsHitsInResult(t *testing.T) {
+	resp := candidateResponse(validMockJSON, genai.FinishReasonStop)
+	resp.ModelVersion = "gemini-test-mock-001"
+	resp.ResponseID = "response-1"
+	resp.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 10, CandidatesTokenCount: 5, TotalTokenCount: 15}
+	cache := genaistructbuilder.NewResponseCache(genaistructbuilder.NewMemoryCache(0, 0), genaistructbuilder.CacheOptions{})
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput]((&RecordingMock{Respond: respondWith(resp)}).GenerateContent, genaistructbuilder.WithMiddleware(cache.Middleware()))
+
+	g := &generator.PromptGenerator[JobSearchOutput]{Prompt: "x"}
+	var results []genaistructbuilder.Result[JobSearchOutput]
+	for range 2 {
+		result, err := builder.BuildWithResult(context.Background(), g, "gemini-test-mock")
+		if err != nil {
+			t.Fatalf("❌ BuildWithResult failed: %v", err)
+		}
+		results = append(results, result)
+	}
+	if results[0].CacheHits != 0 {
+		t.Errorf("❌ expected the first build to miss the cache, got %+v", results[0])
+	}
+	hit := results[1]
+	if hit.CacheHits != 1 || hit.Attempts != 1 || hit.FinishReason != genai.FinishReasonStop ||
+		hit.ModelVersion != "gemini-test-mock-001" || hit.RequestID != "response-1" || hit.Usage.TotalTokens != 15 || hit.Raw == "" {
+		t.Errorf("❌ expected the cached response to be recorded, got %+v", hit)
+	}
+}
@@ -0,0 +1,375 @@
+package genaistructbuilder
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/darwishdev/genaistructbuilder/internal/buildctx"
+	"google.golang.org/genai"
+)
+
+// CreateCachedContentFunc matches the signature of client.Caches.Create.
+type CreateCachedContentFunc func(ctx context.Context, model string, config *genai.CreateCachedContentConfig) (*genai.CachedContent, error)
+
+// UpdateCachedContentFunc matches the signature of client.Caches.Update.
+type UpdateCachedContentFunc func(ctx context.Context, name string, config *genai.UpdateCachedContentConfig) (*genai.CachedContent, error)
+
+// DeleteCachedContentFunc matches the signature of client.Caches.Delete.
+type DeleteCachedContentFunc func(ctx context.Context, name string, config *genai.DeleteCachedContentConfig) (*genai.DeleteCachedContentResponse, error)
+
+// DefaultContextCacheTTL is the lifetime of a cached prefix when none is set.
+const DefaultContextCacheTTL = time.Hour
+
+// DefaultMinPrefixTokens is the smallest prefix the API accepts for a cached
+// content, the minimum of Gemini 2.5 Flash; larger models require more.
+const DefaultMinPrefixTokens = 1024
+
+// DefaultCreateRetryAfter is how long a prefix whose cache could not be
+// created is sent uncached before Create is tried again.
+const DefaultCreateRetryAfter = time.Minute
+
+// ContextCacheOptions configures a ContextCache.
+type ContextCacheOptions struct {
+	// Create creates the cached content. Nil sends every request uncached.
+	Create CreateCachedContentFunc
+	// Update extends the TTL of a cached prefix that is about to expire. Nil
+	// lets it expire and creates a new one.
+	Update UpdateCachedContentFunc
+	// Delete removes cached prefixes that were replaced. Nil leaves them to expire.
+	Delete DeleteCachedContentFunc
+	// TTL is the lifetime requested for a cached prefix. Zero uses DefaultContextCacheTTL.
+	TTL time.Duration
+	// RefreshBefore is how close to expiry a cached prefix is refreshed. Zero
+	// uses a quarter of TTL.
+	RefreshBefore time.Duration
+	// MinPrefixTokens skips caching prefixes smaller than this, since the API
+	// rejects caches below a model-specific minimum. Zero uses
+	// DefaultMinPrefixTokens; a negative value caches prefixes of any size.
+	MinPrefixTokens int
+	// Counter measures prefixes for MinPrefixTokens. Nil uses EstimateTokens.
+	Counter TokenCounter
+	// MaxEntries is how many cached prefixes are kept per model. Zero keeps one,
+	// so a changed instruction or example set replaces the previous cache.
+	MaxEntries int
+	// CreateRetryAfter is how long a prefix is sent uncached after Create
+	// failed for it. It doubles with every consecutive failure, up to TTL.
+	// Zero uses DefaultCreateRetryAfter.
+	CreateRetryAfter time.Duration
+}
+
+// ContextCacheStats counts what a ContextCache did.
+type ContextCacheStats struct {
+	Created     int64
+	Reused      int64
+	Refreshed   int64
+	Invalidated int64
+	// Failed counts Create calls that failed.
+	Failed int64
+}
+
+// ContextCache is a middleware that moves the static prefix of a request, the
+// system instruction and the few-shot examples, into a Gemini cached content
+// and sends only the dynamic input with GenerateContentConfig.CachedContent.
+// Flat examples are cached as a user content ahead of the request, so with a
+// cache the model reads them before the input rather than after it.
+// Prefixes are identified by a hash of their content, so editing instructions
+// or examples creates a new cache and retires the old one.
+//
+// Cache API calls run without blocking requests for other prefixes; concurrent
+// requests for the same prefix wait for a single Create or Update.
+type ContextCache struct {
+	opts ContextCacheOptions
+
+	mu       sync.Mutex
+	entries  map[string][]*contextCacheEntry
+	inflight map[string]chan struct{}
+	failures map[string]contextCacheFailure
+
+	created, reused, refreshed, invalidated, failed atomic.Int64
+}
+
+type contextCacheEntry struct {
+	hash     string
+	name     string
+	expires  time.Time
+	lastUsed time.Time
+}
+
+// contextCacheFailure remembers a failed Create so the prefix is not retried
+// on every request.
+type contextCacheFailure struct {
+	err   error
+	count int
+	until time.Time
+}
+
+// NewContextCache returns a ContextCache using opts.
+func NewContextCache(opts ContextCacheOptions) *ContextCache {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultContextCacheTTL
+	}
+	if opts.RefreshBefore <= 0 {
+		opts.RefreshBefore = opts.TTL / 4
+	}
+	if opts.MinPrefixTokens == 0 {
+		opts.MinPrefixTokens = DefaultMinPrefixTokens
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 1
+	}
+	if opts.Counter == nil {
+		opts.Counter = EstimateTokens
+	}
+	if opts.CreateRetryAfter <= 0 {
+		opts.CreateRetryAfter = DefaultCreateRetryAfter
+	}
+	return &ContextCache{
+		opts:     opts,
+		entries:  map[string][]*contextCacheEntry{},
+		inflight: map[string]chan struct{}{},
+		failures: map[string]contextCacheFailure{},
+	}
+}
+
+// Stats returns the counters collected so far.
+func (c *ContextCache) Stats() ContextCacheStats {
+	return ContextCacheStats{
+		Created:     c.created.Load(),
+		Reused:      c.reused.Load(),
+		Refreshed:   c.refreshed.Load(),
+		Invalidated: c.invalidated.Load(),
+		Failed:      c.failed.Load(),
+	}
+}
+
+// Middleware returns the context caching middleware, for WithMiddleware or Chain.
+// Failures to create a cache are logged and the request is sent uncached.
+func (c *ContextCache) Middleware() Middleware {
+	return func(next GenerateContentFunc) GenerateContentFunc {
+		return func(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+			if c.opts.Create == nil || config == nil || config.CachedContent != "" || len(contents) == 0 {
+				return next(ctx, model, contents, config)
+			}
+			prefix, rest := splitCacheablePrefix(ctx, contents)
+			if config.SystemInstruction == nil && len(prefix) == 0 {
+				return next(ctx, model, contents, config)
+			}
+			logger := loggerFromContext(ctx)
+			if c.opts.MinPrefixTokens > 0 {
+				counted := prefix
+				if config.SystemInstruction != nil {
+					counted = append([]*genai.Content{config.SystemInstruction}, prefix...)
+				}
+				tokens, err := c.opts.Counter(ctx, model, counted)
+				if err != nil || tokens < c.opts.MinPrefixTokens {
+					return next(ctx, model, contents, config)
+				}
+			}
+			hash, err := RequestKey(model, prefix, &genai.GenerateContentConfig{
+				SystemInstruction: config.SystemInstruction,
+				Tools:             config.Tools,
+				ToolConfig:        config.ToolConfig,
+			})
+			if err != nil {
+				logger.WarnContext(ctx, "context cache key failed", "model", model, "error", err)
+				return next(ctx, model, contents, config)
+			}
+			name, err := c.acquire(ctx, model, hash, prefix, config)
+			if err != nil {
+				logger.WarnContext(ctx, "context cache unavailable, sending full request", "model", model, "error", err)
+				return next(ctx, model, contents, config)
+			}
+
+			cached := *config
+			cached.SystemInstruction = nil
+			cached.Tools = nil
+			cached.ToolConfig = nil
+			cached.CachedContent = name
+			resp, err := next(ctx, model, rest, &cached)
+			if code, ok := apiErrorCode(err); ok && (code == http.StatusNotFound || code == http.StatusForbidden) {
+				// The cache was deleted or expired behind our back.
+				logger.WarnContext(ctx, "cached content rejected, sending full request", "model", model, "cached_content", name, "error", err)
+				c.forget(model, hash)
+				return next(ctx, model, contents, config)
+			}
+			return resp, err
+		}
+	}
+}
+
+// splitCacheablePrefix splits contents into the static prefix to cache and the
+// rest to send with it. Without a CacheablePrefix in ctx everything but the
+// last content is static. Flat examples closing the request turn move into the
+// prefix as a user content of their own, and the turn keeps the request parts.
+func splitCacheablePrefix(ctx context.Context, contents []*genai.Content) (prefix, rest []*genai.Content) {
+	mark, ok := buildctx.Value[buildctx.CacheablePrefix](ctx)
+	if !ok || mark.Contents >= len(contents) {
+		mark = buildctx.CacheablePrefix{Contents: len(contents) - 1}
+	}
+	turn := contents[mark.Contents]
+	if mark.Parts <= 0 || turn == nil || mark.Parts >= len(turn.Parts) {
+		return contents[:mark.Contents], contents[mark.Contents:]
+	}
+	split := len(turn.Parts) - mark.Parts
+	prefix = append(slices.Clone(contents[:mark.Contents]), &genai.Content{Role: turn.Role, Parts: turn.Parts[split:]})
+	rest = append([]*genai.Content{{Role: turn.Role, Parts: turn.Parts[:split]}}, contents[mark.Contents+1:]...)
+	return prefix, rest
+}
+
+// acquire returns the name of the cached content for hash, creating or
+// refreshing it as needed. Only one caller per hash talks to the cache API at
+// a time; the others wait for it and then use its outcome.
+func (c *ContextCache) acquire(ctx context.Context, model, hash string, prefix []*genai.Content, config *genai.GenerateContentConfig) (string, error) {
+	for {
+		c.mu.Lock()
+		if wait, ok := c.inflight[hash]; ok {
+			c.mu.Unlock()
+			select {
+			case <-wait:
+				continue
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		now := time.Now()
+		if failure, ok := c.failures[hash]; ok && now.Before(failure.until) {
+			c.mu.Unlock()
+			return "", failure.err
+		}
+		entry := c.find(model, hash)
+		if entry != nil && now.Before(entry.expires) && (c.opts.Update == nil || entry.expires.Sub(now) >= c.opts.RefreshBefore) {
+			entry.lastUsed = now
+			c.mu.Unlock()
+			c.reused.Add(1)
+			return entry.name, nil
+		}
+		done := make(chan struct{})
+		c.inflight[hash] = done
+		c.mu.Unlock()
+
+		name, err := c.renew(ctx, model, hash, entry, prefix, config)
+		c.mu.Lock()
+		delete(c.inflight, hash)
+		close(done)
+		c.mu.Unlock()
+		return name, err
+	}
+}
+
+// renew refreshes entry when it is still alive, or creates the cached content
+// for hash. It is called without c.mu held.
+func (c *ContextCache) renew(ctx context.Context, model, hash string, entry *contextCacheEntry, prefix []*genai.Content, config *genai.GenerateContentConfig) (string, error) {
+	logger := loggerFromContext(ctx)
+	if entry != nil && time.Now().Before(entry.expires) {
+		updated, err := c.opts.Update(ctx, entry.name, &genai.UpdateCachedContentConfig{TTL: c.opts.TTL})
+		c.mu.Lock()
+		now := time.Now()
+		if err != nil {
+			logger.WarnContext(ctx, "context cache refresh failed", "model", model, "cached_content", entry.name, "error", err)
+		} else {
+			entry.expires = expiry(updated, now, c.opts.TTL)
+			c.refreshed.Add(1)
+		}
+		if now.Before(entry.expires) {
+			entry.lastUsed = now
+			c.mu.Unlock()
+			c.reused.Add(1)
+			return entry.name, nil
+		}
+		c.mu.Unlock()
+	}
+
+	created, err := c.opts.Create(ctx, model, &genai.CreateCachedContentConfig{
+		TTL:               c.opts.TTL,
+		DisplayName:       "genaistructbuilder-" + hash[:16],
+		Contents:          prefix,
+		SystemInstruction: config.SystemInstruction,
+		Tools:             config.Tools,
+		ToolConfig:        config.ToolConfig,
+	})
+	c.mu.Lock()
+	now := time.Now()
+	entries := slices.DeleteFunc(c.entries[model], func(e *contextCacheEntry) bool { return e.hash == hash })
+	if err != nil {
+		c.entries[model] = entries
+		c.recordFailure(hash, err, now)
+		c.mu.Unlock()
+		c.failed.Add(1)
+		return "", err
+	}
+	delete(c.failures, hash)
+	entries = append(entries, &contextCacheEntry{hash: hash, name: created.Name, expires: expiry(created, now, c.opts.TTL), lastUsed: now})
+	var evicted []*contextCacheEntry
+	for len(entries) > c.opts.MaxEntries {
+		oldest := 0
+		for i, e := range entries {
+			if e.lastUsed.Before(entries[oldest].lastUsed) {
+				oldest = i
+			}
+		}
+		evicted = append(evicted, entries[oldest])
+		entries = slices.Delete(entries, oldest, oldest+1)
+	}
+	c.entries[model] = entries
+	c.mu.Unlock()
+
+	c.created.Add(1)
+	logger.InfoContext(ctx, "created context cache", "model", model, "cached_content", created.Name, "prefix_contents", len(prefix))
+	for _, e := range evicted {
+		c.invalidate(ctx, model, e)
+	}
+	return created.Name, nil
+}
+
+// recordFailure backs off Create for hash. Failures whose backoff ended more
+// than a TTL ago are dropped. It must be called with c.mu held.
+func (c *ContextCache) recordFailure(hash string, err error, now time.Time) {
+	for h, failure := range c.failures {
+		if now.Sub(failure.until) > c.opts.TTL {
+			delete(c.failures, h)
+		}
+	}
+	failure := c.failures[hash]
+	failure.err = err
+	failure.count++
+	backoff := c.opts.CreateRetryAfter << min(failure.count-1, 16)
+	failure.until = now.Add(min(backoff, max(c.opts.TTL, c.opts.CreateRetryAfter)))
+	c.failures[hash] = failure
+}
+
+// find returns the entry for hash. It must be called with c.mu held.
+func (c *ContextCache) find(model, hash string) *contextCacheEntry {
+	i := slices.IndexFunc(c.entries[model], func(e *contextCacheEntry) bool { return e.hash == hash })
+	if i < 0 {
+		return nil
+	}
+	return c.entries[model][i]
+}
+
+func (c *ContextCache) invalidate(ctx context.Context, model string, entry *contextCacheEntry) {
+	c.invalidated.Add(1)
+	loggerFromContext(ctx).InfoContext(ctx, "invalidated context cache", "model", model, "cached_content", entry.name)
+	if c.opts.Delete == nil {
+		return
+	}
+	if _, err := c.opts.Delete(ctx, entry.name, nil); err != nil {
+		loggerFromContext(ctx).WarnContext(ctx, "context cache delete failed", "model", model, "cached_content", entry.name, "error", err)
+	}
+}
+
+func (c *ContextCache) forget(model, hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[model] = slices.DeleteFunc(c.entries[model], func(e *contextCacheEntry) bool { return e.hash == hash })
+}
+
+func expiry(cached *genai.CachedContent, now time.Time, ttl time.Duration) time.Time {
+	if cached != nil && !cached.ExpireTime.IsZero() {
+		return cached.ExpireTime
+	}
+	return now.Add(ttl)
+}
@@ -0,0 +1,322 @@
+package genaistructbuilder_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	genai "google.golang.org/genai"
+)
+
+// fakeCachedContents records cache API calls the way client.Caches would see them.
+type fakeCachedContents struct {
+	mu      sync.Mutex
+	created []*genai.CreateCachedContentConfig
+	updated []string
+	deleted []string
+	ttl     time.Duration
+	fail    error
+	// wait, when set, runs before every Create to hold it back.
+	wait  func(model string)
+	calls atomic.Int32
+}
+
+func (f *fakeCachedContents) Create(ctx context.Context, model string, config *genai.CreateCachedContentConfig) (*genai.CachedContent, error) {
+	f.calls.Add(1)
+	if f.wait != nil {
+		f.wait(model)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	f.created = append(f.created, config)
+	return &genai.CachedContent{
+		Name:       fmt.Sprintf("cachedContents/%d", len(f.created)),
+		Model:      model,
+		ExpireTime: time.Now().Add(f.ttl),
+	}, nil
+}
+
+func (f *fakeCachedContents) Update(ctx context.Context, name string, config *genai.UpdateCachedContentConfig) (*genai.CachedContent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updated = append(f.updated, name)
+	return &genai.CachedContent{Name: name, ExpireTime: time.Now().Add(config.TTL)}, nil
+}
+
+func (f *fakeCachedContents) Delete(ctx context.Context, name string, config *genai.DeleteCachedContentConfig) (*genai.DeleteCachedContentResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, name)
+	return &genai.DeleteCachedContentResponse{}, nil
+}
+
+func (f *fakeCachedContents) options(ttl time.Duration) genaistructbuilder.ContextCacheOptions {
+	f.ttl = ttl
+	return genaistructbuilder.ContextCacheOptions{Create: f.Create, Update: f.Update, Delete: f.Delete, TTL: ttl, MinPrefixTokens: -1}
+}
+
+func newContextCacheGenerator(instructions string) *generator.PromptGenerator[JobSearchOutput] {
+	return &generator.PromptGenerator[JobSearchOutput]{
+		Prompt:        "Find Go seniors in Egypt",
+		Instructions:  instructions,
+		ExampleFormat: genaistructbuilder.ExampleFormatTurns,
+		Examples: []genaistructbuilder.PromptExample[JobSearchOutput]{
+			{Prompt: "first example", Response: JobSearchOutput{JobTitle: "First"}},
+			{Prompt: "second example", Response: JobSearchOutput{JobTitle: "Second"}},
+		},
+	}
+}
+
+func TestGenAiStructBuilder_ContextCache_ReusesAndInvalidates(t *testing.T) {
+	fake := &fakeCachedContents{}
+	cache := genaistructbuilder.NewContextCache(fake.options(time.Hour))
+	mock := &RecordingMock{Outputs: []string{validMockJSON}}
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+		mock.GenerateContent,
+		genaistructbuilder.WithMiddleware(cache.Middleware()),
+	)
+
+	var output JobSearchOutput
+	for range 2 {
+		if err := builder.Build(newContextCacheGenerator("Extract fields."), "gemini-test-mock", &output); err != nil {
+			t.Fatalf("❌ Build failed: %v", err)
+		}
+	}
+	if len(fake.created) != 1 {
+		t.Fatalf("❌ expected one cached content, got %d", len(fake.created))
+	}
+	created := fake.created[0]
+	if len(created.Contents) != 4 || created.SystemInstruction == nil || created.SystemInstruction.Parts[0].Text != "Extract fields." || created.TTL != time.Hour {
+		t.Errorf("❌ expected the instruction and example turns to be cached, got %+v", created)
+	}
+	contents := mock.Contents()
+	for i, config := range mock.Configs() {
+		if config.CachedContent != "cachedContents/1" || config.SystemInstruction != nil || len(contents[i]) != 1 {
+			t.Errorf("❌ call %d: expected only the request with the cached content, got %d contents and %+v", i, len(contents[i]), config)
+		}
+	}
+
+	if err := builder.Build(newContextCacheGenerator("Extract fields carefully."), "gemini-test-mock", &output); err != nil {
+		t.Fatalf("❌ Build failed: %v", err)
+	}
+	if len(fake.created) != 2 || len(fake.deleted) != 1 || fake.deleted[0] != "cachedContents/1" {
+		t.Errorf("❌ expected changed instructions to replace the cache, created %d deleted %v", len(fake.created), fake.deleted)
+	}
+	if stats := cache.Stats(); stats.Created != 2 || stats.Reused != 1 || stats.Invalidated != 1 {
+		t.Errorf("❌ unexpected stats: %+v", stats)
+	}
+}
+
+func TestGenAiStructBuilder_ContextCache_RefreshesBeforeExpiry(t *testing.T) {
+	fake := &fakeCachedContents{}
+	opts := fake.options(100 * time.Millisecond)
+	opts.RefreshBefore = 90 * time.Millisecond
+	cache := genaistructbuilder.NewContextCache(opts)
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](MockGenerateContentFunc, genaistructbuilder.WithMiddleware(cache.Middleware()))
+
+	var output JobSearchOutput
+	for range 2 {
+		if err := builder.Build(newContextCacheGenerator("Extract fields."), "gemini-test-mock", &output); err != nil {
+			t.Fatalf("❌ Build failed: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if len(fake.created) != 1 || len(fake.updated) != 1 || cache.Stats().Refreshed != 1 {
+		t.Errorf("❌ expected one refresh of the cached content, created %d updated %v", len(fake.created), fake.updated)
+	}
+}
+
+func TestGenAiStructBuilder_ContextCache_RepairTurnsStayDynamic(t *testing.T) {
+	fake := &fakeCachedContents{}
+	cache := genaistructbuilder.NewContextCache(fake.options(time.Hour))
+	mock := &RecordingMock{Outputs: []string{"not json", validMockJSON}}
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+		mock.GenerateContent,
+		genaistructbuilder.WithMiddleware(cache.Middleware()),
+		genaistructbuilder.WithRepairAttempts(1),
+	)
+	var output JobSearchOutput
+	if err := builder.Build(newContextCacheGenerator("Extract fields."), "gemini-test-mock", &output); err != nil {
+		t.Fatalf("❌ Build failed: %v", err)
+	}
+	contents, configs := mock.Contents(), mock.Configs()
+	if len(fake.created) != 1 || len(contents) != 2 || len(contents[1]) != 3 || configs[1].CachedContent != "cachedContents/1" {
+		t.Errorf("❌ expected the repair to reuse the cache and send request plus repair turns, created %d, sent %d contents", len(fake.created), len(contents[1]))
+	}
+}
+
+func TestGenAiStructBuilder_ContextCache_CachesFlatExamples(t *testing.T) {
+	fake := &fakeCachedContents{}
+	cache := genaistructbuilder.NewContextCache(fake.options(time.Hour))
+	mock := &RecordingMock{Outputs: []string{validMockJSON}}
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+		mock.GenerateContent,
+		genaistructbuilder.WithMiddleware(cache.Middleware()),
+	)
+	gen := newContextCacheGenerator("Extract fields.")
+	gen.ExampleFormat = genaistructbuilder.ExampleFormatFlat
+
+	var output JobSearchOutput
+	for range 2 {
+		if err := builder.Build(gen, "gemini-test-mock", &output); err != nil {
+			t.Fatalf("❌ Build failed: %v", err)
+		}
+	}
+	if len(fake.created) != 1 || len(fake.created[0].Contents) != 1 {
+		t.Fatalf("❌ expected the flat examples cached as one content, got %+v", fake.created)
+	}
+	if cached := contentsText(fake.created[0].Contents); !strings.Contains(cached, "first example") || strings.Contains(cached, "Find Go seniors") {
+		t.Errorf("❌ expected only the examples in the cached content, got %q", cached)
+	}
+	contents := mock.Contents()
+	for i, config := range mock.Configs() {
+		sent := contentsText(contents[i])
+		if config.CachedContent != "cachedContents/1" || !strings.Contains(sent, "Find Go seniors") || strings.Contains(sent, "first example") {
+			t.Errorf("❌ call %d: expected only the request with the cached content, got %q and %+v", i, sent, config)
+		}
+	}
+}
+
+func TestGenAiStructBuilder_ContextCache_NilCreateSendsUncached(t *testing.T) {
+	cache := genaistructbuilder.NewContextCache(genaistructbuilder.ContextCacheOptions{MinPrefixTokens: -1})
+	mock := &RecordingMock{Outputs: []string{validMockJSON}}
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+		mock.GenerateContent,
+		genaistructbuilder.WithMiddleware(cache.Middleware()),
+	)
+	var output JobSearchOutput
+	if err := builder.Build(newContextCacheGenerator("Extract fields."), "gemini-test-mock", &output); err != nil {
+		t.Fatalf("❌ Build failed: %v", err)
+	}
+	if config := mock.Configs()[0]; config.CachedContent != "" || config.SystemInstruction == nil || len(mock.Contents()[0]) != 5 {
+		t.Errorf("❌ expected the full request without a cached content, got %d contents and %+v", len(mock.Contents()[0]), config)
+	}
+	if stats := cache.Stats(); stats != (genaistructbuilder.ContextCacheStats{}) {
+		t.Errorf("❌ expected no cache activity, got %+v", stats)
+	}
+}
+
+func TestGenAiStructBuilder_ContextCache_FallsBackToFullRequest(t *testing.T) {
+	fake := &fakeCachedContents{fail: errors.New("quota exceeded")}
+	opts := fake.options(time.Hour)
+	cache := genaistructbuilder.NewContextCache(opts)
+	mock := &RecordingMock{Outputs: []string{validMockJSON}}
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+		mock.GenerateContent,
+		genaistructbuilder.WithMiddleware(cache.Middleware()),
+	)
+	var output JobSearchOutput
+	if err := builder.Build(newContextCacheGenerator("Extract fields."), "gemini-test-mock", &output); err != nil {
+		t.Fatalf("❌ Build failed: %v", err)
+	}
+	contents, configs := mock.Contents(), mock.Configs()
+	if len(contents[0]) != 5 || configs[0].CachedContent != "" || configs[0].SystemInstruction == nil {
+		t.Errorf("❌ expected the full request when the cache cannot be created, got %d contents", len(contents[0]))
+	}
+
+	fake.fail = nil
+	opts.MinPrefixTokens = 100000
+	builder = genaistructbuilder.NewStructBuilder[JobSearchOutput](MockGenerateContentFunc, genaistructbuilder.WithMiddleware(genaistructbuilder.NewContextCache(opts).Middleware()))
+	if err := builder.Build(newContextCacheGenerator("Extract fields."), "gemini-test-mock", &output); err != nil {
+		t.Fatalf("❌ Build failed: %v", err)
+	}
+	if len(fake.created) != 0 {
+		t.Error("❌ expected prefixes below MinPrefixTokens not to be cached")
+	}
+}
+
+func TestGenAiStructBuilder_ContextCache_BacksOffAfterCreateFailure(t *testing.T) {
+	fake := &fakeCachedContents{fail: errors.New("cached content is too small")}
+	opts := fake.options(time.Hour)
+	opts.CreateRetryAfter = 50 * time.Millisecond
+	cache := genaistructbuilder.NewContextCache(opts)
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](MockGenerateContentFunc, genaistructbuilder.WithMiddleware(cache.Middleware()))
+
+	var output JobSearchOutput
+	build := func() {
+		if err := builder.Build(newContextCacheGenerator("Extract fields."), "gemini-test-mock", &output); err != nil {
+			t.Fatalf("❌ Build failed: %v", err)
+		}
+	}
+	for range 5 {
+		build()
+	}
+	if calls := fake.calls.Load(); calls != 1 || cache.Stats().Failed != 1 {
+		t.Errorf("❌ expected a failed Create to be remembered, got %d Create calls, %+v", calls, cache.Stats())
+	}
+	time.Sleep(60 * time.Millisecond)
+	build()
+	if calls := fake.calls.Load(); calls != 2 {
+		t.Errorf("❌ expected Create to be retried after the backoff, got %d calls", calls)
+	}
+}
+
+func TestGenAiStructBuilder_ContextCache_DefaultMinPrefixTokens(t *testing.T) {
+	fake := &fakeCachedContents{}
+	opts := fake.options(time.Hour)
+	opts.MinPrefixTokens = 0
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](MockGenerateContentFunc, genaistructbuilder.WithMiddleware(genaistructbuilder.NewContextCache(opts).Middleware()))
+	var output JobSearchOutput
+	if err := builder.Build(newContextCacheGenerator("Extract fields."), "gemini-test-mock", &output); err != nil {
+		t.Fatalf("❌ Build failed: %v", err)
+	}
+	if calls := fake.calls.Load(); calls != 0 {
+		t.Errorf("❌ expected prefixes below DefaultMinPrefixTokens not to be cached, got %d Create calls", calls)
+	}
+}
+
+func TestGenAiStructBuilder_ContextCache_ConcurrentRequests(t *testing.T) {
+	release := make(chan struct{})
+	fake := &fakeCachedContents{wait: func(model string) {
+		if model == "gemini-slow" {
+			<-release
+		}
+	}}
+	cache := genaistructbuilder.NewContextCache(fake.options(time.Hour))
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](MockGenerateContentFunc, genaistructbuilder.WithMiddleware(cache.Middleware()))
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var output JobSearchOutput
+			if err := builder.Build(newContextCacheGenerator("Extract fields."), "gemini-slow", &output); err != nil {
+				t.Errorf("❌ Build failed: %v", err)
+			}
+		}()
+	}
+
+	fast := make(chan error, 1)
+	go func() {
+		var output JobSearchOutput
+		fast <- builder.Build(newContextCacheGenerator("Extract fields."), "gemini-fast", &output)
+	}()
+	select {
+	case err := <-fast:
+		if err != nil {
+			t.Errorf("❌ Build failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("❌ a pending Create for one prefix must not block other prefixes")
+	}
+	close(release)
+	wg.Wait()
+
+	if calls := fake.calls.Load(); calls != 2 {
+		t.Errorf("❌ expected one Create per prefix, got %d", calls)
+	}
+	if stats := cache.Stats(); stats.Created != 2 || stats.Reused != 7 {
+		t.Errorf("❌ expected concurrent requests to share the created cache, got %+v", stats)
+	}
+}
@@ -0,0 +1,205 @@
+package genaistructbuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+var (
+	// ErrCanceled is returned when the caller's context is canceled before the
+	// generation completes.
+	ErrCanceled = errors.New("generation canceled")
+	// ErrDeadlineExceeded is returned when the caller's context deadline passes
+	// before the generation completes.
+	ErrDeadlineExceeded = errors.New("generation deadline exceeded")
+	// ErrNoCandidates is returned when the model response holds no candidate,
+	// or its candidate holds no text.
+	ErrNoCandidates = errors.New("no response candidates received from model")
+	// ErrBlocked matches every *BlockedError.
+	ErrBlocked = errors.New("generation blocked")
+	// ErrMaxTokens matches a *FinishError for output cut off at the output token limit.
+	ErrMaxTokens = errors.New("model output truncated at max output tokens")
+	// ErrUnexpectedFinish matches a *FinishError for any other finish reason
+	// that leaves no usable output, such as OTHER or MALFORMED_FUNCTION_CALL.
+	ErrUnexpectedFinish = errors.New("model stopped unexpectedly")
+	// ErrUnsupportedMIMEType is returned when a FileRelationGenerator file has a
+	// MIME type that can be sent neither as text nor as inline media.
+	ErrUnsupportedMIMEType = errors.New("unsupported file MIME type")
+	// ErrExampleSelection wraps failures of an ExampleSelector.
+	ErrExampleSelection = errors.New("example selection failed")
+	// ErrOverBudget matches every *BudgetError.
+	ErrOverBudget = errors.New("request over the input token budget")
+)
+
+// BudgetError is returned when the request needs more input tokens than the
+// TokenBudget allows before any example is added.
+type BudgetError struct {
+	Model          string
+	InputTokens    int
+	MaxInputTokens int
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("request needs %d input tokens without examples, over the budget of %d", e.InputTokens, e.MaxInputTokens)
+}
+
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrOverBudget
+}
+
+// FinishError is returned when the candidate stopped for a reason other than
+// STOP that is not a block. Raw holds whatever text was produced.
+type FinishError struct {
+	FinishReason  genai.FinishReason
+	FinishMessage string
+	Raw           string
+}
+
+func (e *FinishError) Error() string {
+	msg := fmt.Sprintf("model stopped with finish reason %s", e.FinishReason)
+	if e.FinishMessage != "" {
+		msg += ": " + e.FinishMessage
+	}
+	return msg
+}
+
+func (e *FinishError) Is(target error) bool {
+	if e.FinishReason == genai.FinishReasonMaxTokens {
+		return target == ErrMaxTokens
+	}
+	return target == ErrUnexpectedFinish
+}
+
+// BlockedError is returned when the prompt or the response was blocked. A
+// blocked prompt sets BlockReason; a blocked response sets FinishReason.
+type BlockedError struct {
+	BlockReason        genai.BlockedReason
+	BlockReasonMessage string
+	FinishReason       genai.FinishReason
+	FinishMessage      string
+	SafetyRatings      []*genai.SafetyRating
+}
+
+func (e *BlockedError) Error() string {
+	if e.BlockReason != "" {
+		msg := fmt.Sprintf("prompt blocked (%s)", e.BlockReason)
+		if e.BlockReasonMessage != "" {
+			msg += ": " + e.BlockReasonMessage
+		}
+		return msg
+	}
+	msg := fmt.Sprintf("response blocked (%s)", e.FinishReason)
+	if e.FinishMessage != "" {
+		msg += ": " + e.FinishMessage
+	}
+	return msg
+}
+
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+// DecodeError is returned when the model output is not valid JSON for T or,
+// with output validation enabled, does not satisfy the response schema. Raw
+// holds the output as received.
+type DecodeError struct {
+	Raw string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("invalid model output: %v", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaError is returned when the response schema cannot be parsed or derived.
+type SchemaError struct {
+	Err error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("invalid response schema: %v", e.Err)
+}
+
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// ProviderError is returned when the GenerateContentFunc call itself fails.
+// Code is the HTTP status of a genai.APIError, or zero for other failures.
+type ProviderError struct {
+	Model string
+	Code  int
+	Err   error
+}
+
+func (e *ProviderError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("model %s failed with status %d: %v", e.Model, e.Code, e.Err)
+	}
+	return fmt.Sprintf("model %s failed: %v", e.Model, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// NewProviderError wraps err returned by the GenerateContentFunc for model.
+func NewProviderError(model string, err error) *ProviderError {
+	code, _ := apiErrorCode(err)
+	return &ProviderError{Model: model, Code: code, Err: err}
+}
+
+// contextError classifies err against the state of ctx. Errors caused by the
+// caller's context are wrapped with ErrCanceled or ErrDeadlineExceeded while
+// keeping the original cause reachable through errors.Is.
+func contextError(ctx context.Context, err error) error {
+	if errors.Is(err, ErrCanceled) || errors.Is(err, ErrDeadlineExceeded) {
+		return err
+	}
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return fmt.Errorf("%w: %w", ErrDeadlineExceeded, err)
+	case context.Canceled:
+		return fmt.Errorf("%w: %w", ErrCanceled, err)
+	}
+	return err
+}
+
+// Violation is a single schema rule broken by the model output. Path is a JSON
+// pointer (RFC 6901) to the offending value; the empty string is the document root.
+// Rule is the JSON Schema keyword that failed, such as "enum" or "pattern".
+// Message never quotes the output; Value holds the offending value, which may
+// be candidate data and is not part of Error.
+type Violation struct {
+	Path    string
+	Rule    string
+	Message string
+	Value   any
+}
+
+// ValidationError lists every place where the model output does not satisfy
+// the response schema.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "model output does not match schema (%d violations)", len(e.Violations))
+	for _, v := range e.Violations {
+		path := v.Path
+		if path == "" {
+			path = "/"
+		}
+		fmt.Fprintf(&b, "\n  %s: %s", path, v.Message)
+	}
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package genaistructbuilder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	genai "google.golang.org/genai"
+)
+
+func candidateResponse(text string, reason genai.FinishReason) *genai.GenerateContentResponse {
+	return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+		Content:      genai.NewContentFromText(text, genai.RoleModel),
+		FinishReason: reason,
+	}}}
+}
+
+func TestGenAiStructBuilder_ErrorTaxonomy_AllRealGenerators(t *testing.T) {
+	tests := []struct {
+		name  string
+		resp  *genai.GenerateContentResponse
+		err   error
+		check func(t *testing.T, err error)
+	}{
+		{
+			name: "provider",
+			err:  genai.APIError{Code: 400, Message: "bad request"},
+			check: func(t *testing.T, err error) {
+				var providerErr *genaistructbuilder.ProviderError
+				var apiErr genai.APIError
+				if !errors.As(err, &providerErr) || providerErr.Code != 400 || providerErr.Model != "gemini-test-mock" || !errors.As(err, &apiErr) {
+					t.Errorf("❌ expected a ProviderError wrapping the APIError, got %v", err)
+				}
+			},
+		},
+		{
+			name: "no candidates",
+			resp: &genai.GenerateContentResponse{},
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, genaistructbuilder.ErrNoCandidates) {
+					t.Errorf("❌ expected ErrNoCandidates, got %v", err)
+				}
+			},
+		},
+		{
+			name: "prompt blocked",
+			resp: &genai.GenerateContentResponse{PromptFeedback: &genai.GenerateContentResponsePromptFeedback{
+				BlockReason:   genai.BlockedReasonSafety,
+				SafetyRatings: []*genai.SafetyRating{{Category: genai.HarmCategoryHarassment, Blocked: true}},
+			}},
+			check: func(t *testing.T, err error) {
+				var blockedErr *genaistructbuilder.BlockedError
+				if !errors.Is(err, genaistructbuilder.ErrBlocked) || !errors.As(err, &blockedErr) ||
+					blockedErr.BlockReason != genai.BlockedReasonSafety || len(blockedErr.SafetyRatings) != 1 {
+					t.Errorf("❌ expected a BlockedError with the prompt feedback, got %v", err)
+				}
+			},
+		},
+		{
+			name: "response blocked",
+			resp: candidateResponse("", genai.FinishReasonSafety),
+			check: func(t *testing.T, err error) {
+				var blockedErr *genaistructbuilder.BlockedError
+				if !errors.As(err, &blockedErr) || blockedErr.FinishReason != genai.FinishReasonSafety {
+					t.Errorf("❌ expected a BlockedError with the finish reason, got %v", err)
+				}
+			},
+		},
+		{
+			name: "truncated",
+			resp: candidateResponse(`{"skills": ["Go"`, genai.FinishReasonMaxTokens),
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, genaistructbuilder.ErrMaxTokens) {
+					t.Errorf("❌ expected ErrMaxTokens, got %v", err)
+				}
+			},
+		},
+		{
+			name: "decode",
+			resp: candidateResponse("not json", genai.FinishReasonStop),
+			check: func(t *testing.T, err error) {
+				var decodeErr *genaistructbuilder.DecodeError
+				if !errors.As(err, &decodeErr) || decodeErr.Raw != "not json" {
+					t.Errorf("❌ expected a DecodeError carrying the raw output, got %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range newGeneratorCases(t) {
+		for _, tt := range tests {
+			t.Run(tc.Name+"/"+tt.name, func(t *testing.T) {
+				mock := &RecordingMock{Err: func(int) error { return tt.err }, Respond: respondWith(tt.resp)}
+				builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+				var output JobSearchOutput
+				err := builder.Build(tc.Generator, "gemini-test-mock", &output)
+				if err == nil {
+					t.Fatal("❌ expected an error")
+				}
+				tt.check(t, err)
+			})
+		}
+	}
+}
+
+func TestGenAiStructBuilder_ErrorTaxonomy_SchemaAndRepair(t *testing.T) {
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](MockGenerateContentFunc)
+	var output JobSearchOutput
+	err := builder.Build(&generator.PromptGenerator[JobSearchOutput]{Prompt: "x", Schema: []byte("{not json")}, "gemini-test-mock", &output)
+	var schemaErr *genaistructbuilder.SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Errorf("❌ expected a SchemaError, got %v", err)
+	}
+
+	builder = genaistructbuilder.NewStructBuilder[JobSearchOutput]((&RecordingMock{Outputs: []string{"nope"}}).GenerateContent, genaistructbuilder.WithRepairAttempts(1))
+	err = builder.Build(&generator.PromptGenerator[JobSearchOutput]{Prompt: "x"}, "gemini-test-mock", &output)
+	var decodeErr *genaistructbuilder.DecodeError
+	var repairErr *genaistructbuilder.RepairError
+	if !errors.As(err, &repairErr) || !errors.As(err, &decodeErr) || decodeErr.Raw != "nope" {
+		t.Errorf("❌ expected a RepairError holding DecodeErrors, got %v", err)
+	}
+}
+
+func TestGenAiStructBuilder_ErrorTaxonomy_GeneratorFailures(t *testing.T) {
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](MockGenerateContentFunc)
+	var output JobSearchOutput
+
+	err := builder.Build(&generator.FileRelationGenerator[JobSearchOutput]{RelationRecordFile: []byte("x"), FileMIMEType: "application/zip"}, "gemini-test-mock", &output)
+	if !errors.Is(err, genaistructbuilder.ErrUnsupportedMIMEType) {
+		t.Errorf("❌ expected ErrUnsupportedMIMEType, got %v", err)
+	}
+
+	selectorErr := errors.New("embedding service down")
+	err = builder.Build(&generator.PromptGenerator[JobSearchOutput]{
+		Prompt:   "x",
+		Examples: []genaistructbuilder.PromptExample[JobSearchOutput]{{Prompt: "y"}},
+		ExampleSelector: genaistructbuilder.ExampleSelectorFunc(func(ctx context.Context, input string, candidates []genaistructbuilder.ExampleCandidate) ([]int, error) {
+			return nil, selectorErr
+		}),
+	}, "gemini-test-mock", &output)
+	if !errors.Is(err, genaistructbuilder.ErrExampleSelection) || !errors.Is(err, selectorErr) {
+		t.Errorf("❌ expected ErrExampleSelection wrapping the selector error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g := &generator.PromptGenerator[JobSearchOutput]{Prompt: "x"}
+	if err := g.Execute(ctx, MockGenerateContentFunc, "gemini-test-mock", &output); !errors.Is(err, genaistructbuilder.ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("❌ expected ErrCanceled from a generator run with a done context, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package genaistructbuilder_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+)
+
+func TestLoadPromptExamples_JSONL(t *testing.T) {
+	input := `{"prompt": "Backend devs in Riyadh", "response": {"skills": [], "company": [], "industry": "", "location": "Riyadh, Saudi Arabia", "job_title": "Backend Developer", "yearsof_experience_to": 0, "yearsof_experience_from": 0}}
+
+{"category": "companies", "prompt": "Ex-Amazon engineers", "response": {"skills": [], "company": ["Amazon"], "industry": "", "location": "", "job_title": "", "yearsof_experience_to": 0, "yearsof_experience_from": 0}}
+`
+	set, err := generator.LoadPromptExamples[JobSearchOutput](strings.NewReader(input), generator.ExampleFileJSONL, nil)
+	if err != nil {
+		t.Fatalf("❌ LoadPromptExamples failed: %v", err)
+	}
+	if len(set.Examples) != 1 || set.Examples[0].Response.Location != "Riyadh, Saudi Arabia" {
+		t.Errorf("❌ unexpected plain examples: %+v", set.Examples)
+	}
+	if got := set.Categorized["companies"]; len(got) != 1 || got[0].Prompt != "Ex-Amazon engineers" || got[0].Response.Company[0] != "Amazon" {
+		t.Errorf("❌ unexpected categorized examples: %+v", set.Categorized)
+	}
+}
+
+func TestLoadPromptExamples_ReportsInvalidLines(t *testing.T) {
+	input := `{"prompt": "ok", "response": {"skills": [], "company": [], "industry": "", "location": "", "job_title": "", "yearsof_experience_to": 0, "yearsof_experience_from": 0}}
+{"prompt": "wrong names", "response": {"Skills": [], "Company": [], "Industry": "", "Location": "", "JobTitle": "", "YearsOfExperienceTo": 0, "YearsOfExperienceFrom": 0}}
+{"prompt": "wrong types", "response": {"skills": [], "company": "Google", "industry": [], "location": "", "job_title": "", "yearsof_experience_to": 0, "yearsof_experience_from": 0}}
+{"input": "wrong key", "response": {}}
+`
+	_, err := generator.LoadPromptExamples[JobSearchOutput](strings.NewReader(input), generator.ExampleFileJSONL, nil)
+	if err == nil {
+		t.Fatal("❌ expected invalid examples to be rejected")
+	}
+	for _, want := range []string{"3 invalid examples", "line 2:", "/JobTitle", "line 3:", "/company", "line 4:", `unknown example key "input"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("❌ expected error to mention %q, got:\n%v", want, err)
+		}
+	}
+	var validationErr *genaistructbuilder.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("❌ expected a ValidationError in the chain, got %T", err)
+	}
+}
+
+func TestLoadRelationExamples_YAMLWithExplicitSchema(t *testing.T) {
+	schema := []byte(`{"type": "object", "properties": {"industry": {"type": "string", "enum": ["Finance", "Healthcare"]}}, "required": ["industry"]}`)
+	input := `- category: finance
+  relation_record_json:
+    title: Accountant
+    company: Fintech Inc
+  response:
+    industry: Finance
+- relation_record_json: '{"title": "Nurse"}'
+  response:
+    industry: Healthcare
+- relation_record_json: '{"title": "Chef"}'
+  response:
+    industry: Hospitality
+`
+	_, err := generator.LoadRelationExamples[JobSearchOutput](strings.NewReader(input), generator.ExampleFileYAML, schema)
+	if err == nil || !strings.Contains(err.Error(), "line 10:") || !strings.Contains(err.Error(), "/industry") {
+		t.Fatalf("❌ expected the enum violation to be reported on line 10, got: %v", err)
+	}
+
+	set, err := generator.LoadRelationExamples[JobSearchOutput](strings.NewReader(input[:strings.Index(input, "- relation_record_json: '{\"title\": \"Chef\"}'")]), generator.ExampleFileYAML, schema)
+	if err != nil {
+		t.Fatalf("❌ LoadRelationExamples failed: %v", err)
+	}
+	if got := set.Categorized["finance"]; len(got) != 1 || got[0].RelationRecordJSON != `{"company":"Fintech Inc","title":"Accountant"}` {
+		t.Errorf("❌ expected the inline record to be compacted to JSON, got %+v", got)
+	}
+	if len(set.Examples) != 1 || set.Examples[0].RelationRecordJSON != `{"title": "Nurse"}` || set.Examples[0].Response.Industry != "Healthcare" {
+		t.Errorf("❌ unexpected plain examples: %+v", set.Examples)
+	}
+}
+
+func TestLoadPromptExamplesFile(t *testing.T) {
+	set, err := generator.LoadPromptExamplesFile[JobSearchOutput](filepath.Join("examples", "job_prompts.jsonl"), nil)
+	if err != nil {
+		t.Fatalf("❌ bundled examples do not match JobSearchOutput: %v", err)
+	}
+	if len(set.Categorized) == 0 {
+		t.Error("❌ expected bundled examples to be categorized")
+	}
+
+	path := filepath.Join(t.TempDir(), "examples.yaml")
+	if err := os.WriteFile(path, []byte("- prompt: x\n  response: {skills: 1}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generator.LoadPromptExamplesFile[JobSearchOutput](path, nil); err == nil || !strings.Contains(err.Error(), path+":1:") {
+		t.Errorf("❌ expected a file:line error, got: %v", err)
+	}
+	if _, err := generator.LoadPromptExamplesFile[JobSearchOutput]("examples.txt", nil); err == nil {
+		t.Error("❌ expected unsupported extensions to be rejected")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
 	genai "google.golang.org/genai"
 )
 
@@ -44,6 +45,10 @@ func main() {
 		APIKey:  apiKey,
 		Backend: genai.BackendGeminiAPI,
 	})
+	if err != nil {
+		panic(err)
+	}
+	builder := genaistructbuilder.NewStructBuilder[EmployeeInfo](client.Models.GenerateContent)
 
 	// --- 3. Define Generation Parameters ---
 
@@ -51,14 +56,17 @@ func main() {
 	instructions := "You are an HR Profile Generator. Fill in the required fields to create a complete employee profile."
 
 	// Few-shot examples (optional, using the type-safe struct)
-	examples := map[string]EmployeeInfo{
-		"Profile for a Junior UX Designer named Sarah who just finished one project.": {
-			EmployeeID: "UX-102",
-			FullName:   "Sarah Connor",
-			Position:   "Junior UX Designer",
-			Skills:     []string{"Figma", "User Research", "Prototyping"},
-			Projects:   []Project{{Name: "Mobile App Redesign", DurationMos: 4}},
-			IsManager:  false,
+	examples := []genaistructbuilder.PromptExample[EmployeeInfo]{
+		{
+			Prompt: "Profile for a Junior UX Designer named Sarah who just finished one project.",
+			Response: EmployeeInfo{
+				EmployeeID: "UX-102",
+				FullName:   "Sarah Connor",
+				Position:   "Junior UX Designer",
+				Skills:     []string{"Figma", "User Research", "Prototyping"},
+				Projects:   []Project{{Name: "Mobile App Redesign", DurationMos: 4}},
+				IsManager:  false,
+			},
 		},
 	}
 
@@ -67,15 +75,12 @@ func main() {
 
 	fmt.Println("➡️ Requesting structured employee profile from Go Struct...")
 
-	err = genaistructbuilder.GenerateFromStructGeneric(
-		ctx,
-		client,
-		MODEL,
-		prompt,
-		instructions,
-		examples,
-		&output, // Pass a pointer to the type-safe struct
-	)
+	err = builder.BuildContext(ctx, &generator.PromptGenerator[EmployeeInfo]{
+		Prompt:       prompt,
+		Instructions: instructions,
+		Examples:     examples,
+		// Schema is left nil so it is derived from EmployeeInfo
+	}, MODEL, &output) // Pass a pointer to the type-safe struct
 
 	// 5. Handle Response
 	if err != nil {
@@ -2,17 +2,23 @@ package main
 
 import (
 	"context"
+	_ "embed"
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
 	genai "google.golang.org/genai"
 )
 
 // The model we will use for generation
 const MODEL = "gemini-2.5-flash"
 
+//go:embed job_prompts.jsonl
+var jobPromptExamples string
+
 func main() {
 	// 1. Initialize GenAI Client
 	ctx := context.Background()
@@ -26,10 +32,13 @@ func main() {
 		APIKey:  apiKey,
 		Backend: genai.BackendGeminiAPI,
 	})
-	// defer client.Close()
+	if err != nil {
+		panic(err)
+	}
 
 	// 2. Initialize Struct Builder
-	builder := genaistructbuilder.NewGenAIStructBuilder(client)
+	// Use map[string]interface{} as the generic type T
+	builder := genaistructbuilder.NewStructBuilder[map[string]interface{}](client.Models.GenerateContent)
 
 	// --- 3. Define Generation Parameters ---
 
@@ -46,8 +55,8 @@ Output must strictly match this JSON schema:
   "job_title": string,
   "yearsof_experience_from": int,
   "yearsof_experience_to": int,
-  "industry": [string],
-  "company": string
+  "industry": string,
+  "company": [string]
 }
 
 Rules:
@@ -72,193 +81,8 @@ Seniority Mapping:
 - Skills should be extracted as specific technologies, tools, or expertise areas mentioned in the prompt.
 `
 
-	var examples = map[string]map[string]interface{}{
-		// 🧩 Job title + seniority mapping
-		"Looking for someone mid-level in backend work, maybe with Java and Spring.": {
-			"Skills":                []string{"Java", "Spring"},
-			"Location":              "",
-			"JobTitle":              "Backend Developer",
-			"YearsOfExperienceFrom": 3,
-			"YearsOfExperienceTo":   5,
-			"Industry":              "Technology",
-			"Company":               []string{},
-		},
-		"We need a team lead for our React project.": {
-			"Skills":                []string{"React"},
-			"Location":              "",
-			"JobTitle":              "Team Lead",
-			"YearsOfExperienceFrom": 8,
-			"YearsOfExperienceTo":   12,
-			"Industry":              "Technology",
-			"Company":               []string{},
-		},
-		"Hiring junior to senior cloud engineers with AWS experience.": {
-			"Skills":                []string{"AWS", "Cloud Engineering"},
-			"Location":              "",
-			"JobTitle":              "Cloud Engineer",
-			"YearsOfExperienceFrom": 1,
-			"YearsOfExperienceTo":   10,
-			"Industry":              "Technology",
-			"Company":               []string{},
-		},
-		"Need someone who can handle accounting for a small firm.": {
-			"Skills":                []string{"Accounting"},
-			"Location":              "",
-			"JobTitle":              "Accountant",
-			"YearsOfExperienceFrom": 0,
-			"YearsOfExperienceTo":   2,
-			"Industry":              "Finance",
-			"Company":               []string{},
-		},
-
-		// 🌍 Locations
-		"Backend devs wanted in Riyadh.": {
-			"Skills":                []string{"Backend Development"},
-			"Location":              "Riyadh, Saudi Arabia",
-			"JobTitle":              "Backend Developer",
-			"YearsOfExperienceFrom": 0,
-			"YearsOfExperienceTo":   0,
-			"Industry":              "Technology",
-			"Company":               []string{},
-		},
-		"Looking for software testers in Egypt.": {
-			"Skills":                []string{"Testing"},
-			"Location":              "Egypt",
-			"JobTitle":              "Software Tester",
-			"YearsOfExperienceFrom": 0,
-			"YearsOfExperienceTo":   0,
-			"Industry":              "Technology",
-			"Company":               []string{},
-		},
-		"Hiring Flutter developers in Amman, Jordan.": {
-			"Skills":                []string{"Flutter"},
-			"Location":              "Amman, Jordan",
-			"JobTitle":              "Flutter Developer",
-			"YearsOfExperienceFrom": 0,
-			"YearsOfExperienceTo":   0,
-			"Industry":              "Technology",
-			"Company":               []string{},
-		},
-
-		// 🏢 Companies
-		"We want someone who worked previously at Amazon or Microsoft.": {
-			"Skills":                []string{},
-			"Location":              "",
-			"JobTitle":              "",
-			"YearsOfExperienceFrom": 0,
-			"YearsOfExperienceTo":   0,
-			"Industry":              "",
-			"Company":               []string{"Amazon", "Microsoft"},
-		},
-		"Candidates from FAANG companies preferred.": {
-			"Skills":                []string{},
-			"Location":              "",
-			"JobTitle":              "",
-			"YearsOfExperienceFrom": 5,
-			"YearsOfExperienceTo":   10,
-			"Industry":              "Technology",
-			"Company":               []string{"Facebook", "Amazon", "Apple", "Netflix", "Google"},
-		},
-		"Senior PMs with experience at a top-tier tech giant like Meta.": {
-			"Skills":                []string{"Project Management"},
-			"Location":              "",
-			"JobTitle":              "Senior Project Manager",
-			"YearsOfExperienceFrom": 5,
-			"YearsOfExperienceTo":   10,
-			"Industry":              "Technology",
-			"Company":               []string{"Meta"},
-		},
-
-		// 💼 Industries
-		"Hiring Python developers for a fintech startup.": {
-			"Skills":                []string{"Python"},
-			"Location":              "",
-			"JobTitle":              "Software Developer",
-			"YearsOfExperienceFrom": 0,
-			"YearsOfExperienceTo":   0,
-			"Industry":              "Finance",
-			"Company":               []string{},
-		},
-		"We’re building an AI product in healthcare.": {
-			"Skills":                []string{"AI", "Machine Learning"},
-			"Location":              "",
-			"JobTitle":              "AI Engineer",
-			"YearsOfExperienceFrom": 3,
-			"YearsOfExperienceTo":   5,
-			"Industry":              "Healthcare",
-			"Company":               []string{},
-		},
-
-		// 🧠 Years of experience
-		"We need engineers with 7+ years in backend systems.": {
-			"Skills":                []string{"Backend Development"},
-			"Location":              "",
-			"JobTitle":              "Backend Engineer",
-			"YearsOfExperienceFrom": 7,
-			"YearsOfExperienceTo":   7,
-			"Industry":              "Technology",
-			"Company":               []string{},
-		},
-		"Prefer people with around 3 to 5 years of experience.": {
-			"Skills":                []string{},
-			"Location":              "",
-			"JobTitle":              "",
-			"YearsOfExperienceFrom": 3,
-			"YearsOfExperienceTo":   5,
-			"Industry":              "",
-			"Company":               []string{},
-		},
-		"Hiring senior data scientists with 10 years experience.": {
-			"Skills":                []string{"Data Science"},
-			"Location":              "",
-			"JobTitle":              "Senior Data Scientist",
-			"YearsOfExperienceFrom": 10,
-			"YearsOfExperienceTo":   10,
-			"Industry":              "Technology",
-			"Company":               []string{},
-		},
-
-		// 🌐 Combined prompts
-		"Find junior Python developers in Cairo with Django skills for a fintech company.": {
-			"Skills":                []string{"Python", "Django"},
-			"Location":              "Cairo, Egypt",
-			"JobTitle":              "Junior Python Developer",
-			"YearsOfExperienceFrom": 1,
-			"YearsOfExperienceTo":   3,
-			"Industry":              "Finance",
-			"Company":               []string{},
-		},
-		"Senior cloud architects in Dubai familiar with AWS, GCP, and Kubernetes.": {
-			"Skills":                []string{"AWS", "GCP", "Kubernetes"},
-			"Location":              "Dubai, UAE",
-			"JobTitle":              "Senior Cloud Architect",
-			"YearsOfExperienceFrom": 5,
-			"YearsOfExperienceTo":   10,
-			"Industry":              "Technology",
-			"Company":               []string{},
-		},
-		"Data analysts at Amazon with 3+ years of SQL experience.": {
-			"Skills":                []string{"SQL", "Data Analysis"},
-			"Location":              "",
-			"JobTitle":              "Data Analyst",
-			"YearsOfExperienceFrom": 3,
-			"YearsOfExperienceTo":   3,
-			"Industry":              "Technology",
-			"Company":               []string{"Amazon"},
-		},
-		"Healthcare AI researchers with TensorFlow and NLP expertise.": {
-			"Skills":                []string{"TensorFlow", "NLP"},
-			"Location":              "",
-			"JobTitle":              "AI Researcher",
-			"YearsOfExperienceFrom": 5,
-			"YearsOfExperienceTo":   10,
-			"Industry":              "Healthcare",
-			"Company":               []string{},
-		},
-	}
-
 	fmt.Println("🧠 TAL CLI — Job Prompt Extractor (Seniority-Aware)")
-	fmt.Println("Type a job search prompt (or 'exit' to quit):\n")
+	fmt.Println("Type a job search prompt (or 'exit' to quit):")
 
 	// reader := bufio.NewReader(os.Stdin)
 	schemaJSON := `{
@@ -284,22 +108,24 @@ Seniority Mapping:
 }`
 
 	prompt := "Find Pythong Javascript Seniors on egypt"
+	// Examples live in job_prompts.jsonl and are checked against the schema
+	// before they are sent, so a misspelled field fails here with its line number.
+	examples, err := generator.LoadPromptExamples[map[string]interface{}](strings.NewReader(jobPromptExamples), generator.ExampleFileJSONL, []byte(schemaJSON))
+	if err != nil {
+		panic(err)
+	}
 	// 4. Call the Builder Function
-	// Use map[string]interface{} as the generic type T
 	var output map[string]interface{}
 
 	fmt.Println("➡️ Requesting structured data from JSON Schema...")
 
-	err = builder.GenerateFromchema(
-		ctx,
-		client,
-		MODEL,
-		prompt,
-		instructions,
-		examples,
-		schemaJSON,
-		&output,
-	)
+	err = builder.BuildContext(ctx, &generator.PromptGenerator[map[string]interface{}]{
+		Prompt:              prompt,
+		Instructions:        instructions,
+		Examples:            examples.Examples,
+		CategorizedExamples: examples.Categorized,
+		Schema:              []byte(schemaJSON),
+	}, MODEL, &output)
 
 	// 5. Handle Response
 	if err != nil {
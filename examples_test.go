@@ -0,0 +1,223 @@
+package genaistructbuilder_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	genai "google.golang.org/genai"
+)
+
+// contentsText joins the text of every part sent to the model.
+func contentsText(contents []*genai.Content) string {
+	var b strings.Builder
+	for _, c := range contents {
+		for _, part := range c.Parts {
+			b.WriteString(part.Text)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func newExampleGeneratorCases(format genaistructbuilder.ExampleFormat) []generatorCase {
+	promptExamples := []genaistructbuilder.PromptExample[JobSearchOutput]{
+		{Prompt: "Looking for someone mid-level in backend work", Response: JobSearchOutput{JobTitle: "Backend Developer", YearsOfExperienceFrom: 3}},
+		{Prompt: "We need a team lead for our React project", Response: JobSearchOutput{JobTitle: "Team Lead", Skills: []string{"React"}}},
+	}
+	categorizedPrompt := map[string][]genaistructbuilder.PromptExample[JobSearchOutput]{
+		"locations": {{Prompt: "Backend devs wanted in Riyadh", Response: JobSearchOutput{Location: "Riyadh, Saudi Arabia"}}},
+		"companies": {{Prompt: "Candidates from FAANG companies", Response: JobSearchOutput{Company: []string{"Netflix"}}}},
+	}
+	relationExamples := []genaistructbuilder.RelationExample[JobSearchOutput]{
+		{RelationRecordJSON: `{"offer": "backend-offer"}`, Response: JobSearchOutput{JobTitle: "Backend Developer"}},
+	}
+	categorizedRelation := map[string][]genaistructbuilder.RelationExample[JobSearchOutput]{
+		"finance":    {{RelationRecordJSON: `{"offer": "fintech-offer"}`, Response: JobSearchOutput{Industry: "Finance"}}},
+		"healthcare": {{RelationRecordJSON: `{"offer": "healthcare-offer"}`, Response: JobSearchOutput{Industry: "Healthcare"}}},
+	}
+	return []generatorCase{
+		{Name: "PromptGenerator", Generator: &generator.PromptGenerator[JobSearchOutput]{
+			Prompt: "Find Go seniors in Egypt", Examples: promptExamples, CategorizedExamples: categorizedPrompt, ExampleFormat: format,
+		}},
+		{Name: "RelationGenerator", Generator: &generator.RelationGenerator[JobSearchOutput]{
+			RelationRecordJSON: `{"offer": "current"}`, Examples: relationExamples, CategorizedExamples: categorizedRelation, ExampleFormat: format,
+		}},
+		{Name: "FileRelationGenerator", Generator: &generator.FileRelationGenerator[JobSearchOutput]{
+			RelationRecordFile: []byte("current offer"), FileMIMEType: "text/plain", Examples: relationExamples, CategorizedExamples: categorizedRelation, ExampleFormat: format,
+		}},
+	}
+}
+
+func TestGenAiStructBuilder_ExamplesDelivered_AllRealGenerators(t *testing.T) {
+	wantByGenerator := map[string][]string{
+		"PromptGenerator": {
+			"Looking for someone mid-level in backend work", `"job_title": "Backend Developer"`,
+			"We need a team lead for our React project", `"job_title": "Team Lead"`,
+			"Backend devs wanted in Riyadh", `"location": "Riyadh, Saudi Arabia"`, "locations",
+			"Candidates from FAANG companies", `"Netflix"`, "companies",
+		},
+		"RelationGenerator": {
+			`{"offer": "backend-offer"}`, `"job_title": "Backend Developer"`,
+			`{"offer": "fintech-offer"}`, `"industry": "Finance"`, "finance",
+			`{"offer": "healthcare-offer"}`, `"industry": "Healthcare"`, "healthcare",
+		},
+	}
+	wantByGenerator["FileRelationGenerator"] = wantByGenerator["RelationGenerator"]
+
+	for _, format := range []genaistructbuilder.ExampleFormat{genaistructbuilder.ExampleFormatTurns, genaistructbuilder.ExampleFormatFlat} {
+		for _, tc := range newExampleGeneratorCases(format) {
+			t.Run(tc.Name, func(t *testing.T) {
+				mock := &RecordingMock{Outputs: []string{validMockJSON}}
+				builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+
+				var output JobSearchOutput
+				if err := builder.Build(tc.Generator, "gemini-test-mock", &output); err != nil {
+					t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+				}
+				sent := contentsText(mock.Contents()[0])
+				for _, want := range wantByGenerator[tc.Name] {
+					if !strings.Contains(sent, want) {
+						t.Errorf("❌ %s: example text %q was not sent to the model:\n%s", tc.Name, want, sent)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestGenAiStructBuilder_ExampleTurns_AllRealGenerators(t *testing.T) {
+	requestMarkers := map[string]string{
+		"PromptGenerator":       "Find Go seniors in Egypt",
+		"RelationGenerator":     `{"offer": "current"}`,
+		"FileRelationGenerator": "current offer",
+	}
+	for _, tc := range newExampleGeneratorCases(genaistructbuilder.ExampleFormatTurns) {
+		t.Run(tc.Name, func(t *testing.T) {
+			mock := &RecordingMock{Outputs: []string{validMockJSON}}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, "gemini-test-mock", &output); err != nil {
+				t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+			}
+			contents := mock.Contents()[0]
+			// 2 plain + 2 categorized prompt examples, or 1 plain + 2 categorized relation examples.
+			wantTurns := 2*4 + 1
+			if tc.Name != "PromptGenerator" {
+				wantTurns = 2*3 + 1
+			}
+			if len(contents) != wantTurns {
+				t.Fatalf("❌ %s: expected %d turns, got %d", tc.Name, wantTurns, len(contents))
+			}
+			for i, content := range contents[:len(contents)-1] {
+				wantRole := genai.RoleUser
+				if i%2 == 1 {
+					wantRole = genai.RoleModel
+					var decoded JobSearchOutput
+					if err := json.Unmarshal([]byte(content.Parts[0].Text), &decoded); err != nil {
+						t.Errorf("❌ %s: model turn %d does not carry JSON: %v", tc.Name, i, err)
+					}
+				}
+				if content.Role != wantRole {
+					t.Errorf("❌ %s: turn %d has role %q, want %q", tc.Name, i, content.Role, wantRole)
+				}
+				if strings.Contains(contentsText([]*genai.Content{content}), requestMarkers[tc.Name]) {
+					t.Errorf("❌ %s: the real request leaked into example turn %d", tc.Name, i)
+				}
+			}
+			last := contents[len(contents)-1]
+			if last.Role != genai.RoleUser || !strings.Contains(contentsText([]*genai.Content{last}), requestMarkers[tc.Name]) {
+				t.Errorf("❌ %s: the real request must be the final user turn, got %+v", tc.Name, last)
+			}
+		})
+	}
+}
+
+func TestGenAiStructBuilder_ExampleFlat_AllRealGenerators(t *testing.T) {
+	// Generators that never set ExampleFormat keep the flat layout; turns are opt-in.
+	var defaultFormat genaistructbuilder.ExampleFormat
+	for _, tc := range newExampleGeneratorCases(defaultFormat) {
+		t.Run(tc.Name, func(t *testing.T) {
+			mock := &RecordingMock{Outputs: []string{validMockJSON}}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, "gemini-test-mock", &output); err != nil {
+				t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+			}
+			if len(mock.Contents()[0]) != 1 {
+				t.Fatalf("❌ %s: flat examples must stay in a single user turn, got %d turns", tc.Name, len(mock.Contents()[0]))
+			}
+			if sent := contentsText(mock.Contents()[0]); !strings.Contains(sent, "Expected JSON:") || !strings.Contains(sent, "--- Categorized Example Group For Category :") {
+				t.Errorf("❌ %s: expected flattened example text, got:\n%s", tc.Name, sent)
+			}
+		})
+	}
+}
+
+func TestGenAiStructBuilder_CategoryOrdering_AllRealGenerators(t *testing.T) {
+	wantOrders := map[string][2][]string{
+		// sorted default, then explicit order
+		"PromptGenerator":       {{"companies", "locations"}, {"locations", "companies"}},
+		"RelationGenerator":     {{"finance", "healthcare"}, {"healthcare", "finance"}},
+		"FileRelationGenerator": {{"finance", "healthcare"}, {"healthcare", "finance"}},
+	}
+	for _, format := range []genaistructbuilder.ExampleFormat{genaistructbuilder.ExampleFormatTurns, genaistructbuilder.ExampleFormatFlat} {
+		for _, tc := range newExampleGeneratorCases(format) {
+			t.Run(tc.Name, func(t *testing.T) {
+				send := func() string {
+					mock := &RecordingMock{Outputs: []string{validMockJSON}}
+					builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+					var output JobSearchOutput
+					if err := builder.Build(tc.Generator, "gemini-test-mock", &output); err != nil {
+						t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+					}
+					return contentsText(mock.Contents()[0])
+				}
+
+				first := send()
+				for i := 0; i < 20; i++ {
+					if again := send(); again != first {
+						t.Fatalf("❌ %s: contents changed between calls:\n%s\n---\n%s", tc.Name, first, again)
+					}
+				}
+				assertOrder(t, tc.Name, first, wantOrders[tc.Name][0])
+
+				explicit := wantOrders[tc.Name][1]
+				categories := []genaistructbuilder.ExampleCategory{
+					{Name: explicit[0], Description: "the prompt is about " + explicit[0]},
+					{Name: explicit[1]},
+				}
+				switch g := tc.Generator.(type) {
+				case *generator.PromptGenerator[JobSearchOutput]:
+					g.Categories = categories
+				case *generator.RelationGenerator[JobSearchOutput]:
+					g.Categories = categories
+				case *generator.FileRelationGenerator[JobSearchOutput]:
+					g.Categories = categories
+				}
+				ordered := send()
+				assertOrder(t, tc.Name, ordered, explicit)
+				if !strings.Contains(ordered, "the prompt is about "+explicit[0]) {
+					t.Errorf("❌ %s: category description was not rendered:\n%s", tc.Name, ordered)
+				}
+			})
+		}
+	}
+}
+
+func assertOrder(t *testing.T, name, text string, categories []string) {
+	t.Helper()
+	last := -1
+	for _, category := range categories {
+		i := strings.Index(text, category)
+		if i < last {
+			t.Errorf("❌ %s: expected categories in order %v:\n%s", name, categories, text)
+			return
+		}
+		last = i
+	}
+}
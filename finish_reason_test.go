@@ -0,0 +1,79 @@
+package genaistructbuilder_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	genai "google.golang.org/genai"
+)
+
+func TestGenAiStructBuilder_FinishReasons(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *genai.GenerateContentResponse
+		want error
+	}{
+		{"nil response", nil, genaistructbuilder.ErrNoCandidates},
+		{"nil candidate", &genai.GenerateContentResponse{Candidates: []*genai.Candidate{nil}}, genaistructbuilder.ErrNoCandidates},
+		{"nil content", &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonStop}}}, genaistructbuilder.ErrNoCandidates},
+		{"only thoughts", &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+			Content:      &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{Text: "thinking...", Thought: true}}},
+			FinishReason: genai.FinishReasonStop,
+		}}}, genaistructbuilder.ErrNoCandidates},
+		{"recitation", candidateResponse("", genai.FinishReasonRecitation), genaistructbuilder.ErrBlocked},
+		{"prohibited content without content", &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonProhibitedContent}}}, genaistructbuilder.ErrBlocked},
+		{"other", candidateResponse(validMockJSON, genai.FinishReasonOther), genaistructbuilder.ErrUnexpectedFinish},
+		{"malformed function call", candidateResponse("", genai.FinishReasonMalformedFunctionCall), genaistructbuilder.ErrUnexpectedFinish},
+		{"max tokens", candidateResponse(`{"skills": ["Go"`, genai.FinishReasonMaxTokens), genaistructbuilder.ErrMaxTokens},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput]((&RecordingMock{Respond: respondWith(tt.resp)}).GenerateContent)
+			var output JobSearchOutput
+			err := builder.Build(&generator.PromptGenerator[JobSearchOutput]{Prompt: "x"}, "gemini-test-mock", &output)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("❌ expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestGenAiStructBuilder_FinishReasons_TruncatedOutputKept(t *testing.T) {
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput]((&RecordingMock{Respond: respondWith(candidateResponse(`{"skills": ["Go"`, genai.FinishReasonMaxTokens))}).GenerateContent)
+	var output JobSearchOutput
+	err := builder.Build(&generator.PromptGenerator[JobSearchOutput]{Prompt: "x"}, "gemini-test-mock", &output)
+	var finishErr *genaistructbuilder.FinishError
+	if !errors.As(err, &finishErr) || finishErr.FinishReason != genai.FinishReasonMaxTokens || finishErr.Raw != `{"skills": ["Go"` {
+		t.Errorf("❌ expected a FinishError carrying the truncated output, got %v", err)
+	}
+	var decodeErr *genaistructbuilder.DecodeError
+	if errors.As(err, &decodeErr) {
+		t.Error("❌ truncated output must not surface as a DecodeError")
+	}
+}
+
+func TestGenAiStructBuilder_JoinsTextPartsAndSkipsThoughts(t *testing.T) {
+	resp := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+		Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+			{Text: "Let me think about the job title.", Thought: true},
+			{Text: `{"skills": ["Go"], `},
+			nil,
+			{Text: `"job_title": "Backend Developer"}`},
+		}},
+		FinishReason: genai.FinishReasonStop,
+	}}}
+	for _, tc := range newGeneratorCases(t) {
+		t.Run(tc.Name, func(t *testing.T) {
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput]((&RecordingMock{Respond: respondWith(resp)}).GenerateContent)
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, "gemini-test-mock", &output); err != nil {
+				t.Fatalf("❌ Build failed: %v", err)
+			}
+			if output.JobTitle != "Backend Developer" || len(output.Skills) != 1 {
+				t.Errorf("❌ expected the joined text parts to decode, got %+v", output)
+			}
+		})
+	}
+}
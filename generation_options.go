@@ -0,0 +1,79 @@
+package genaistructbuilder
+
+import (
+	"maps"
+
+	"google.golang.org/genai"
+)
+
+// GenerationOptions tunes the GenerateContentConfig of a request. Generators
+// embed it; unset fields fall back to the builder defaults given with
+// WithGenerationDefaults and then to the model defaults, except Temperature
+// which defaults to 0 for reproducible extraction.
+type GenerationOptions struct {
+	Temperature     *float32
+	TopP            *float32
+	TopK            *float32
+	MaxOutputTokens int32
+	Seed            *int32
+	// CandidateCount asks for several candidates; only the first is decoded.
+	CandidateCount int32
+	StopSequences  []string
+	SafetySettings []*genai.SafetySetting
+	// ThinkingConfig sets the thinking budget and whether thoughts are returned.
+	// Thought parts are never decoded as output.
+	ThinkingConfig *genai.ThinkingConfig
+	// Labels are merged with the default labels, overriding equal keys.
+	Labels map[string]string
+}
+
+// WithDefaults returns o with every unset field taken from defaults.
+func (o GenerationOptions) WithDefaults(defaults GenerationOptions) GenerationOptions {
+	if o.Temperature == nil {
+		o.Temperature = defaults.Temperature
+	}
+	if o.TopP == nil {
+		o.TopP = defaults.TopP
+	}
+	if o.TopK == nil {
+		o.TopK = defaults.TopK
+	}
+	if o.MaxOutputTokens == 0 {
+		o.MaxOutputTokens = defaults.MaxOutputTokens
+	}
+	if o.Seed == nil {
+		o.Seed = defaults.Seed
+	}
+	if o.CandidateCount == 0 {
+		o.CandidateCount = defaults.CandidateCount
+	}
+	if o.StopSequences == nil {
+		o.StopSequences = defaults.StopSequences
+	}
+	if o.SafetySettings == nil {
+		o.SafetySettings = defaults.SafetySettings
+	}
+	if o.ThinkingConfig == nil {
+		o.ThinkingConfig = defaults.ThinkingConfig
+	}
+	if len(defaults.Labels) > 0 {
+		labels := maps.Clone(defaults.Labels)
+		maps.Copy(labels, o.Labels)
+		o.Labels = labels
+	}
+	return o
+}
+
+// Apply copies the options onto config.
+func (o GenerationOptions) Apply(config *genai.GenerateContentConfig) {
+	config.Temperature = o.Temperature
+	config.TopP = o.TopP
+	config.TopK = o.TopK
+	config.MaxOutputTokens = o.MaxOutputTokens
+	config.Seed = o.Seed
+	config.CandidateCount = o.CandidateCount
+	config.StopSequences = o.StopSequences
+	config.SafetySettings = o.SafetySettings
+	config.ThinkingConfig = o.ThinkingConfig
+	config.Labels = o.Labels
+}
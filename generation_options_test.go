@@ -0,0 +1,78 @@
+package genaistructbuilder_test
+
+import (
+	"testing"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	genai "google.golang.org/genai"
+)
+
+func TestGenAiStructBuilder_GenerationOptions_AllRealGenerators(t *testing.T) {
+	defaults := genaistructbuilder.GenerationOptions{
+		Temperature:     genai.Ptr[float32](0.7),
+		TopK:            genai.Ptr[float32](40),
+		MaxOutputTokens: 1024,
+		StopSequences:   []string{"END"},
+		SafetySettings: []*genai.SafetySetting{{
+			Category:  genai.HarmCategoryHarassment,
+			Threshold: genai.HarmBlockThresholdBlockOnlyHigh,
+		}},
+		Labels: map[string]string{"team": "search", "env": "prod"},
+	}
+	overrides := genaistructbuilder.GenerationOptions{
+		Temperature:    genai.Ptr[float32](0.2),
+		TopP:           genai.Ptr[float32](0.9),
+		Seed:           genai.Ptr[int32](42),
+		CandidateCount: 1,
+		ThinkingConfig: &genai.ThinkingConfig{IncludeThoughts: true, ThinkingBudget: genai.Ptr[int32](512)},
+		Labels:         map[string]string{"env": "staging"},
+	}
+	generators := map[string]genaistructbuilder.Generator[JobSearchOutput]{
+		"PromptGenerator":       &generator.PromptGenerator[JobSearchOutput]{Prompt: "x", GenerationOptions: overrides},
+		"RelationGenerator":     &generator.RelationGenerator[JobSearchOutput]{RelationRecordJSON: "{}", GenerationOptions: overrides},
+		"FileRelationGenerator": &generator.FileRelationGenerator[JobSearchOutput]{RelationRecordFile: []byte("offer"), FileMIMEType: "text/plain", GenerationOptions: overrides},
+	}
+	for name, g := range generators {
+		t.Run(name, func(t *testing.T) {
+			mock := &RecordingMock{}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent, genaistructbuilder.WithGenerationDefaults(defaults))
+			var output JobSearchOutput
+			if err := builder.Build(g, "gemini-test-mock", &output); err != nil {
+				t.Fatalf("❌ Build failed: %v", err)
+			}
+			config := mock.Configs()[0]
+			if *config.Temperature != 0.2 || *config.TopP != 0.9 || *config.Seed != 42 || config.CandidateCount != 1 {
+				t.Errorf("❌ expected generator options to win, got temperature %v topP %v seed %v", *config.Temperature, *config.TopP, *config.Seed)
+			}
+			if *config.TopK != 40 || config.MaxOutputTokens != 1024 || len(config.StopSequences) != 1 || len(config.SafetySettings) != 1 {
+				t.Errorf("❌ expected builder defaults for unset options, got %+v", config)
+			}
+			if config.ThinkingConfig == nil || !config.ThinkingConfig.IncludeThoughts || *config.ThinkingConfig.ThinkingBudget != 512 {
+				t.Errorf("❌ expected the thinking config to be sent, got %+v", config.ThinkingConfig)
+			}
+			if config.Labels["team"] != "search" || config.Labels["env"] != "staging" {
+				t.Errorf("❌ expected labels to be merged with generator precedence, got %v", config.Labels)
+			}
+		})
+	}
+	if defaults.Labels["env"] != "prod" {
+		t.Error("❌ merging labels must not modify the builder defaults")
+	}
+}
+
+func TestGenAiStructBuilder_GenerationOptions_TemperatureDefaultsToZero(t *testing.T) {
+	mock := &RecordingMock{}
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+	var output JobSearchOutput
+	if err := builder.Build(&generator.PromptGenerator[JobSearchOutput]{Prompt: "x"}, "gemini-test-mock", &output); err != nil {
+		t.Fatalf("❌ Build failed: %v", err)
+	}
+	config := mock.Configs()[0]
+	if config.Temperature == nil || *config.Temperature != 0 {
+		t.Errorf("❌ expected temperature 0 when nothing sets it, got %v", config.Temperature)
+	}
+	if config.TopP != nil || config.MaxOutputTokens != 0 || config.Labels != nil {
+		t.Errorf("❌ expected other options to stay unset, got %+v", config)
+	}
+}
@@ -2,7 +2,9 @@ package genaistructbuilder
 
 import (
 	"context"
+	"time"
 
+	"github.com/darwishdev/genaistructbuilder/internal/buildctx"
 	"google.golang.org/genai"
 )
 
@@ -22,16 +24,79 @@ type Generator[T any] interface {
 
 type StructBuilderInterface[T any] interface {
 	Build(generator Generator[T], model string, output *T) error
+	// BuildContext is like Build but threads ctx through the generator so callers
+	// can cancel in-flight model calls or bound them with a deadline.
+	BuildContext(ctx context.Context, generator Generator[T], model string, output *T) error
+	// BuildWithResult is like BuildContext but also reports usage, timing and the
+	// raw model output. The result is filled as far as the build got, even on error.
+	BuildWithResult(ctx context.Context, generator Generator[T], model string) (Result[T], error)
+	// BuildBatch runs many generators concurrently; see GenAiStructBuilder.BuildBatch.
+	BuildBatch(ctx context.Context, generators []Generator[T], model string, opts BatchOptions) []Result[T]
 }
 type GenAiStructBuilder[T any] struct {
 	generateContent GenerateContentFunc
+	config          builderConfig
 }
 
-func NewStructBuilder[T any](generateContent GenerateContentFunc) StructBuilderInterface[T] {
+func NewStructBuilder[T any](generateContent GenerateContentFunc, opts ...BuilderOption) StructBuilderInterface[T] {
+	config := newBuilderConfig(opts)
 	return &GenAiStructBuilder[T]{
-		generateContent: generateContent,
+		generateContent: Chain(config.middleware...)(config.retry.wrap(collectResponses(generateContent))),
+		config:          config,
 	}
 }
 func (b *GenAiStructBuilder[T]) Build(generator Generator[T], model string, output *T) error {
-	return generator.Execute(context.Background(), b.generateContent, model, output)
+	return b.BuildContext(context.Background(), generator, model, output)
+}
+func (b *GenAiStructBuilder[T]) BuildContext(ctx context.Context, generator Generator[T], model string, output *T) error {
+	if err := ctx.Err(); err != nil {
+		return contextError(ctx, err)
+	}
+	ctx = buildctx.With(ctx, b.config.logger)
+	ctx = buildctx.With(ctx, buildctx.MaxRepairs(b.config.maxRepairs))
+	ctx = buildctx.With(ctx, b.config.schema)
+	ctx = buildctx.With(ctx, b.config.budget)
+	ctx = buildctx.With(ctx, b.config.generation)
+	if err := generator.Execute(ctx, b.generateContent, model, output); err != nil {
+		return contextError(ctx, err)
+	}
+	return nil
+}
+func (b *GenAiStructBuilder[T]) BuildWithResult(ctx context.Context, generator Generator[T], model string) (Result[T], error) {
+	collector := &buildctx.Collector{}
+	start := time.Now()
+	var result Result[T]
+	err := b.BuildContext(buildctx.With(ctx, collector), generator, model, &result.Value)
+	result.Duration = time.Since(start)
+
+	summary := collector.Summary()
+	result.Raw = summary.Raw
+	result.Usage = Usage(summary.Usage)
+	result.ModelVersion = summary.ModelVersion
+	result.FinishReason = summary.FinishReason
+	result.Attempts = summary.Attempts
+	result.CacheHits = summary.CacheHits
+	result.RequestID = summary.RequestID
+	result.Err = err
+	return result, err
+}
+
+// ExampleFormat selects how few-shot examples are sent to the model.
+type ExampleFormat int
+
+const (
+	// ExampleFormatFlat appends the examples as text parts after the request in
+	// a single user turn. It is the zero value. A ContextCache caches them
+	// as a user content ahead of the request.
+	ExampleFormatFlat ExampleFormat = iota
+	// ExampleFormatTurns sends every example as a user turn followed by a model
+	// turn carrying the expected JSON; the real request is the final user turn.
+	ExampleFormatTurns
+)
+
+// ExampleCategory orders a group of CategorizedExamples and describes when it applies.
+type ExampleCategory struct {
+	Name string `json:"name"`
+	// Description tells the model when the examples of this category apply.
+	Description string `json:"description,omitempty"`
 }
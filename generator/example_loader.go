@@ -0,0 +1,268 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/internal"
+	genai "google.golang.org/genai"
+	"gopkg.in/yaml.v3"
+)
+
+// ExampleFileFormat is the encoding of an example file.
+type ExampleFileFormat int
+
+const (
+	// ExampleFileJSONL holds one JSON example object per line.
+	ExampleFileJSONL ExampleFileFormat = iota
+	// ExampleFileYAML holds a YAML sequence of example objects.
+	ExampleFileYAML
+)
+
+// ExampleSet holds loaded examples, ready for a generator's Examples and
+// CategorizedExamples fields.
+type ExampleSet[E any] struct {
+	Examples    []E
+	Categorized map[string][]E
+}
+
+func (s *ExampleSet[E]) add(category string, example E) {
+	if category == "" {
+		s.Examples = append(s.Examples, example)
+		return
+	}
+	if s.Categorized == nil {
+		s.Categorized = map[string][]E{}
+	}
+	s.Categorized[category] = append(s.Categorized[category], example)
+}
+
+// LoadPromptExamples reads prompt examples such as
+//
+//	{"category": "locations", "prompt": "Backend devs wanted in Riyadh", "response": {...}}
+//
+// Every response is validated against schema, or against the schema derived
+// from T when schema is nil, and must not use undeclared properties. All
+// invalid examples are reported together, each with its line number.
+func LoadPromptExamples[T any](r io.Reader, format ExampleFileFormat, schema []byte) (ExampleSet[genaistructbuilder.PromptExample[T]], error) {
+	return loadPromptExamples[T](r, format, schema, "")
+}
+
+// LoadPromptExamplesFile is LoadPromptExamples for a .jsonl, .yaml or .yml file.
+func LoadPromptExamplesFile[T any](path string, schema []byte) (ExampleSet[genaistructbuilder.PromptExample[T]], error) {
+	var set ExampleSet[genaistructbuilder.PromptExample[T]]
+	err := withExampleFile(path, func(r io.Reader, format ExampleFileFormat) (err error) {
+		set, err = loadPromptExamples[T](r, format, schema, path)
+		return err
+	})
+	return set, err
+}
+
+// LoadRelationExamples reads relation examples such as
+//
+//	{"category": "finance", "relation_record_json": {"title": "Accountant"}, "response": {...}}
+//
+// relation_record_json may be a JSON string or an inline JSON value. Responses
+// are validated as in LoadPromptExamples.
+func LoadRelationExamples[T any](r io.Reader, format ExampleFileFormat, schema []byte) (ExampleSet[genaistructbuilder.RelationExample[T]], error) {
+	return loadRelationExamples[T](r, format, schema, "")
+}
+
+// LoadRelationExamplesFile is LoadRelationExamples for a .jsonl, .yaml or .yml file.
+func LoadRelationExamplesFile[T any](path string, schema []byte) (ExampleSet[genaistructbuilder.RelationExample[T]], error) {
+	var set ExampleSet[genaistructbuilder.RelationExample[T]]
+	err := withExampleFile(path, func(r io.Reader, format ExampleFileFormat) (err error) {
+		set, err = loadRelationExamples[T](r, format, schema, path)
+		return err
+	})
+	return set, err
+}
+
+func loadPromptExamples[T any](r io.Reader, format ExampleFileFormat, schema []byte, name string) (ExampleSet[genaistructbuilder.PromptExample[T]], error) {
+	var set ExampleSet[genaistructbuilder.PromptExample[T]]
+	err := loadExamples[T](r, format, schema, name, "prompt", func(category, input string, response T) {
+		set.add(category, genaistructbuilder.PromptExample[T]{Prompt: input, Response: response})
+	})
+	return set, err
+}
+
+func loadRelationExamples[T any](r io.Reader, format ExampleFileFormat, schema []byte, name string) (ExampleSet[genaistructbuilder.RelationExample[T]], error) {
+	var set ExampleSet[genaistructbuilder.RelationExample[T]]
+	err := loadExamples[T](r, format, schema, name, "relation_record_json", func(category, input string, response T) {
+		set.add(category, genaistructbuilder.RelationExample[T]{RelationRecordJSON: input, Response: response})
+	})
+	return set, err
+}
+
+func withExampleFile(path string, load func(io.Reader, ExampleFileFormat) error) error {
+	var format ExampleFileFormat
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		format = ExampleFileJSONL
+	case ".yaml", ".yml":
+		format = ExampleFileYAML
+	default:
+		return fmt.Errorf("unsupported example file extension: %s", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open example file: %w", err)
+	}
+	defer file.Close()
+	return load(file, format)
+}
+
+// exampleRecord is one example as JSON together with the line it starts on.
+type exampleRecord struct {
+	line int
+	raw  []byte
+}
+
+func loadExamples[T any](r io.Reader, format ExampleFileFormat, schemaBytes []byte, name, inputKey string, add func(category, input string, response T)) error {
+	schema, err := internal.ResolveSchema[T](context.Background(), schemaBytes)
+	if err != nil {
+		return fmt.Errorf("failed to resolve example schema: %w", err)
+	}
+	var records []exampleRecord
+	switch format {
+	case ExampleFileJSONL:
+		records, err = readJSONLRecords(r)
+	case ExampleFileYAML:
+		records, err = readYAMLRecords(r)
+	default:
+		err = fmt.Errorf("unknown example file format %d", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read examples%s: %w", inName(name), err)
+	}
+
+	outputType := reflect.TypeFor[T]()
+	var errs []error
+	for _, record := range records {
+		location := fmt.Sprintf("line %d", record.line)
+		if name != "" {
+			location = fmt.Sprintf("%s:%d", name, record.line)
+		}
+		category, input, response, err := decodeExampleRecord(record.raw, inputKey, schema, outputType)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", location, err))
+			continue
+		}
+		var value T
+		if err := json.Unmarshal(internal.ObjectMapsJSON(outputType, response), &value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: response does not decode into %T: %w", location, value, err))
+			continue
+		}
+		add(category, input, value)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d invalid examples%s:\n%w", len(errs), inName(name), errors.Join(errs...))
+	}
+	return nil
+}
+
+func inName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return " in " + name
+}
+
+func decodeExampleRecord(raw []byte, inputKey string, schema *genai.Schema, outputType reflect.Type) (category, input string, response json.RawMessage, err error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return "", "", nil, fmt.Errorf("example must be an object: %w", err)
+	}
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		if key != "category" && key != inputKey && key != "response" {
+			return "", "", nil, fmt.Errorf("unknown example key %q", key)
+		}
+	}
+	if value, ok := fields["category"]; ok {
+		if err := json.Unmarshal(value, &category); err != nil {
+			return "", "", nil, errors.New("category must be a string")
+		}
+	}
+	rawInput, ok := fields[inputKey]
+	if !ok {
+		return "", "", nil, fmt.Errorf("missing %q", inputKey)
+	}
+	if err := json.Unmarshal(rawInput, &input); err != nil {
+		if inputKey == "prompt" {
+			return "", "", nil, errors.New("prompt must be a string")
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, rawInput); err != nil {
+			return "", "", nil, fmt.Errorf("invalid %s: %w", inputKey, err)
+		}
+		input = compact.String()
+	}
+	response, ok = fields["response"]
+	if !ok {
+		return "", "", nil, errors.New(`missing "response"`)
+	}
+	// Maps may be written as objects or as the key/value arrays a derived schema declares.
+	if err := internal.ValidateJSONStrict(schema, internal.KeyValueMapsJSON(outputType, schema, response)); err != nil {
+		return "", "", nil, fmt.Errorf("response does not match the schema: %w", err)
+	}
+	return category, input, response, nil
+}
+
+func readJSONLRecords(r io.Reader) ([]exampleRecord, error) {
+	var records []exampleRecord
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		text, err := reader.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(text); len(trimmed) > 0 {
+			records = append(records, exampleRecord{line: line, raw: trimmed})
+		}
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func readYAMLRecords(r io.Reader) ([]exampleRecord, error) {
+	var document yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&document); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	root := &document
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: expected a sequence of examples", root.Line)
+	}
+	records := make([]exampleRecord, 0, len(root.Content))
+	for _, item := range root.Content {
+		var value any
+		if err := item.Decode(&value); err != nil {
+			return nil, fmt.Errorf("line %d: %w", item.Line, err)
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: example is not representable as JSON: %w", item.Line, err)
+		}
+		records = append(records, exampleRecord{line: item.Line, raw: raw})
+	}
+	return records, nil
+}
@@ -13,37 +13,87 @@ type FileRelationGenerator[T any] struct {
 	RelationEntity      string
 	RelationContext     string
 	RelationRecordFile  []byte
-	temperature         float32
 	FileMIMEType        string
 	Instructions        string
 	Examples            []genaistructbuilder.RelationExample[T]
 	CategorizedExamples map[string][]genaistructbuilder.RelationExample[T]
-	Schema              []byte
+	// Schema is the JSON response schema. When nil it is derived from T.
+	Schema []byte
+	// ValidateOutput checks the model output against Schema before it is decoded.
+	ValidateOutput bool
+	// ExampleFormat selects how examples are sent; the zero value appends them as flat
+	// text and ExampleFormatTurns sends them as conversation turns.
+	ExampleFormat genaistructbuilder.ExampleFormat
+	// Categories orders CategorizedExamples and describes each group. Categories
+	// that are not listed follow in sorted order.
+	Categories []genaistructbuilder.ExampleCategory
+	// GenerationOptions tunes sampling, limits, safety and thinking for this generator.
+	genaistructbuilder.GenerationOptions
+	// ExampleSelector picks the examples sent for the current input. Nil sends all of them.
+	// Text files are matched on their content, media files on RelationContext.
+	ExampleSelector genaistructbuilder.ExampleSelector
 }
 
 func (g *FileRelationGenerator[T]) Execute(ctx context.Context, generateContent genaistructbuilder.GenerateContentFunc, model string, output *T) error {
-	genSchema, err := internal.BuildSchemaFromJson(g.Schema)
+	logger := internal.LoggerFromContext(ctx)
+	logger.DebugContext(ctx, "file relation generator execute",
+		"relation_entity", g.RelationEntity,
+		"instructions", logger.Body(g.Instructions),
+		"file_mime_type", g.FileMIMEType,
+		"file_bytes", len(g.RelationRecordFile),
+		"examples", len(g.Examples),
+		"categorized_examples", len(g.CategorizedExamples),
+		"schema_bytes", len(g.Schema),
+	)
+	genSchema, err := internal.ResolveSchema[T](ctx, g.Schema)
 	if err != nil {
+		logger.ErrorContext(ctx, "failed to build schema", "generator", "file_relation", "error", err)
 		return err
 	}
-	config := internal.GenerateConfig(ctx, g.Instructions, genSchema, g.temperature)
+	config := internal.GenerateConfig(ctx, g.Instructions, genSchema, g.GenerationOptions)
 	processedText, mediaPart, err := internal.FileAdapter(ctx, g.RelationRecordFile, g.FileMIMEType)
 	if err != nil {
-		return fmt.Errorf("❌ file adapter failed: %w", err)
+		logger.ErrorContext(ctx, "file adapter failed", "file_mime_type", g.FileMIMEType, "error", err)
+		return fmt.Errorf("file adapter failed: %w", err)
 	}
-	mainPromptText := fmt.Sprintf(
-		"Task: Generate a %s record based on the provided file content. \nContext: %s",
-		g.RelationEntity,
-		g.RelationContext,
-	)
-	parts := []*genai.Part{{Text: mainPromptText}}
+	parts := []*genai.Part{{Text: g.buildMainPrompt()}}
 	if mediaPart != nil {
 		parts = append(parts, mediaPart)
 		parts = append(parts, &genai.Part{Text: "\nInput File: (See attached media part)"})
 	} else {
 		parts = append(parts, &genai.Part{Text: fmt.Sprintf("\nInput File Content:\n%s", processedText)})
 	}
-	internal.RelationExampleHandler(parts, g.Examples, g.CategorizedExamples)
-	content := []*genai.Content{{Parts: parts}}
-	return internal.ExecuteLLMCall(ctx, generateContent, model, content, config, output)
+	selectionInput := processedText
+	if mediaPart != nil {
+		selectionInput = g.RelationContext
+	}
+	examples, err := internal.SelectExamples(ctx, g.ExampleSelector, selectionInput, internal.RelationExamples(g.Examples, g.CategorizedExamples, g.Categories))
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to select examples", "generator", "file_relation", "error", err)
+		return err
+	}
+	content, err := internal.PackContents(ctx, model, g.Instructions, internal.ExampleLayout{
+		Format:     g.ExampleFormat,
+		InputLabel: "Example Input JSON",
+		RenderInput: func(recordJSON string) string {
+			return fmt.Sprintf("%s\nInput File Content:\n%s", g.buildMainPrompt(), recordJSON)
+		},
+		Schema: genSchema,
+	}, parts, examples)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to pack examples", "generator", "file_relation", "error", err)
+		return err
+	}
+	return internal.ExecuteLLMCall(ctx, generateContent, model, content, config, internal.CallOptions{
+		ValidateOutput: g.ValidateOutput,
+		StaticParts:    internal.StaticParts(content, parts),
+	}, output)
+}
+
+func (g *FileRelationGenerator[T]) buildMainPrompt() string {
+	return fmt.Sprintf(
+		"Task: Generate a %s record based on the provided file content. \nContext: %s",
+		g.RelationEntity,
+		g.RelationContext,
+	)
 }
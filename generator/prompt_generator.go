@@ -2,7 +2,6 @@ package generator
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"github.com/darwishdev/genaistructbuilder"
@@ -15,58 +14,72 @@ type PromptGenerator[T any] struct {
 	Instructions        string
 	Examples            []genaistructbuilder.PromptExample[T]
 	CategorizedExamples map[string][]genaistructbuilder.PromptExample[T]
-	Temperature         float32
-	Schema              []byte
+	// Schema is the JSON response schema. When nil it is derived from T.
+	Schema []byte
+	// ValidateOutput checks the model output against Schema before it is decoded.
+	ValidateOutput bool
+	// ExampleFormat selects how examples are sent; the zero value appends them as flat
+	// text and ExampleFormatTurns sends them as conversation turns.
+	ExampleFormat genaistructbuilder.ExampleFormat
+	// Categories orders CategorizedExamples and describes each group. Categories
+	// that are not listed follow in sorted order.
+	Categories []genaistructbuilder.ExampleCategory
+	// GenerationOptions tunes sampling, limits, safety and thinking for this generator.
+	genaistructbuilder.GenerationOptions
+	// ExampleSelector picks the examples sent for the current input. Nil sends all of them.
+	ExampleSelector genaistructbuilder.ExampleSelector
 }
 
 func (g PromptGenerator[T]) Execute(ctx context.Context, generateContent genaistructbuilder.GenerateContentFunc, model string, output *T) error {
-	fmt.Println("🔧 PROMPT GENERATOR EXECUTE START")
-	fmt.Printf("📝 Instructions: %s\n", g.Instructions)
-	fmt.Printf("📋 User Prompt: %s\n", g.Prompt)
-	fmt.Printf("📊 Examples Count: %d\n", len(g.Examples))
-	fmt.Printf("🏷️  Categorized Examples Count: %d\n", len(g.CategorizedExamples))
-	fmt.Printf("📄 Schema Length: %d bytes\n", len(g.Schema))
+	logger := internal.LoggerFromContext(ctx)
+	logger.DebugContext(ctx, "prompt generator execute",
+		"instructions", logger.Body(g.Instructions),
+		"prompt", logger.Body(g.Prompt),
+		"examples", len(g.Examples),
+		"categorized_examples", len(g.CategorizedExamples),
+		"schema_bytes", len(g.Schema),
+	)
 
 	// Build schema
-	schema, err := internal.BuildSchemaFromJson(g.Schema)
+	schema, err := internal.ResolveSchema[T](ctx, g.Schema)
 	if err != nil {
-		fmt.Printf("❌ Failed to build schema: %v\n", err)
+		logger.ErrorContext(ctx, "failed to build schema", "generator", "prompt", "error", err)
 		return err
 	}
-	fmt.Println("✅ Schema built successfully")
 
 	// Generate config
-	config := internal.GenerateConfig(ctx, g.Instructions, schema, g.Temperature)
-	fmt.Printf("⚙️  Config generated - Temperature: %v\n", config.Temperature)
+	config := internal.GenerateConfig(ctx, g.Instructions, schema, g.GenerationOptions)
 
 	// Build the actual prompt that includes user input
-	fullPrompt := g.buildFullPrompt()
-	fmt.Printf("📦 Full Prompt Length: %d characters\n", len(fullPrompt))
-
+	fullPrompt := g.buildFullPrompt(g.Prompt)
 	parts := []*genai.Part{{Text: fullPrompt}}
-	fmt.Printf("🔢 Parts created: %d\n", len(parts))
 
 	// Add examples
-	internal.ExamplesHandler(parts, g.Examples, g.CategorizedExamples)
-
-	content := []*genai.Content{{Parts: parts}}
-	fmt.Printf("📦 Content blocks: %d\n", len(content))
-
-	fmt.Println("🚀 Calling ExecuteLLMCall...")
-	err = internal.ExecuteLLMCall(ctx, generateContent, model, content, config, output)
+	examples, err := internal.SelectExamples(ctx, g.ExampleSelector, g.Prompt, internal.PromptExamples(g.Examples, g.CategorizedExamples, g.Categories))
 	if err != nil {
-		fmt.Printf("❌ ExecuteLLMCall failed: %v\n", err)
+		logger.ErrorContext(ctx, "failed to select examples", "generator", "prompt", "error", err)
 		return err
 	}
-
-	fmt.Println("✅ PROMPT GENERATOR EXECUTE COMPLETED SUCCESSFULLY")
-	return nil
+	content, err := internal.PackContents(ctx, model, g.Instructions, internal.ExampleLayout{
+		Format:      g.ExampleFormat,
+		InputLabel:  "Example prompt",
+		RenderInput: g.buildFullPrompt,
+		Schema:      schema,
+	}, parts, examples)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to pack examples", "generator", "prompt", "error", err)
+		return err
+	}
+	return internal.ExecuteLLMCall(ctx, generateContent, model, content, config, internal.CallOptions{
+		ValidateOutput: g.ValidateOutput,
+		StaticParts:    internal.StaticParts(content, parts),
+	}, output)
 }
 
 // Helper method to build the complete prompt including user input
-func (g PromptGenerator[T]) buildFullPrompt() string {
+func (g PromptGenerator[T]) buildFullPrompt(prompt string) string {
 	builder := strings.Builder{}
-	builder.WriteString(g.Prompt)
+	builder.WriteString(prompt)
 	builder.WriteString("\n\nPlease extract the structured data from the above prompt.")
 
 	return builder.String()
@@ -12,28 +12,69 @@ import (
 type RelationGenerator[T any] struct {
 	RelationEntity      string
 	RelationContext     string
-	Temperature         float32
 	RelationRecordJSON  string
 	Instructions        string
 	Examples            []genaistructbuilder.RelationExample[T]
 	CategorizedExamples map[string][]genaistructbuilder.RelationExample[T]
-	Schema              []byte
+	// Schema is the JSON response schema. When nil it is derived from T.
+	Schema []byte
+	// ValidateOutput checks the model output against Schema before it is decoded.
+	ValidateOutput bool
+	// ExampleFormat selects how examples are sent; the zero value appends them as flat
+	// text and ExampleFormatTurns sends them as conversation turns.
+	ExampleFormat genaistructbuilder.ExampleFormat
+	// Categories orders CategorizedExamples and describes each group. Categories
+	// that are not listed follow in sorted order.
+	Categories []genaistructbuilder.ExampleCategory
+	// GenerationOptions tunes sampling, limits, safety and thinking for this generator.
+	genaistructbuilder.GenerationOptions
+	// ExampleSelector picks the examples sent for the current input. Nil sends all of them.
+	ExampleSelector genaistructbuilder.ExampleSelector
 }
 
 func (g *RelationGenerator[T]) Execute(ctx context.Context, generateContent genaistructbuilder.GenerateContentFunc, model string, output *T) error {
-	schema, err := internal.BuildSchemaFromJson(g.Schema)
+	logger := internal.LoggerFromContext(ctx)
+	logger.DebugContext(ctx, "relation generator execute",
+		"relation_entity", g.RelationEntity,
+		"instructions", logger.Body(g.Instructions),
+		"relation_record", logger.Body(g.RelationRecordJSON),
+		"examples", len(g.Examples),
+		"categorized_examples", len(g.CategorizedExamples),
+		"schema_bytes", len(g.Schema),
+	)
+	schema, err := internal.ResolveSchema[T](ctx, g.Schema)
 	if err != nil {
+		logger.ErrorContext(ctx, "failed to build schema", "generator", "relation", "error", err)
 		return err
 	}
-	config := internal.GenerateConfig(ctx, g.Instructions, schema, g.Temperature)
-	mainPrompt := fmt.Sprintf(
+	config := internal.GenerateConfig(ctx, g.Instructions, schema, g.GenerationOptions)
+	parts := []*genai.Part{{Text: g.buildMainPrompt(g.RelationRecordJSON)}}
+	examples, err := internal.SelectExamples(ctx, g.ExampleSelector, g.RelationRecordJSON, internal.RelationExamples(g.Examples, g.CategorizedExamples, g.Categories))
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to select examples", "generator", "relation", "error", err)
+		return err
+	}
+	content, err := internal.PackContents(ctx, model, g.Instructions, internal.ExampleLayout{
+		Format:      g.ExampleFormat,
+		InputLabel:  "Example Input JSON",
+		RenderInput: g.buildMainPrompt,
+		Schema:      schema,
+	}, parts, examples)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to pack examples", "generator", "relation", "error", err)
+		return err
+	}
+	return internal.ExecuteLLMCall(ctx, generateContent, model, content, config, internal.CallOptions{
+		ValidateOutput: g.ValidateOutput,
+		StaticParts:    internal.StaticParts(content, parts),
+	}, output)
+}
+
+func (g *RelationGenerator[T]) buildMainPrompt(recordJSON string) string {
+	return fmt.Sprintf(
 		"Task: Generate a %s record based on the provided input JSON.\nContext: %s\nInput JSON: %s",
 		g.RelationEntity,
 		g.RelationContext,
-		g.RelationRecordJSON,
+		recordJSON,
 	)
-	parts := []*genai.Part{{Text: mainPrompt}}
-	internal.RelationExampleHandler(parts, g.Examples, g.CategorizedExamples)
-	content := []*genai.Content{{Parts: parts}}
-	return internal.ExecuteLLMCall(ctx, generateContent, model, content, config, output)
 }
@@ -1,9 +1,11 @@
-package genaistructbuilder
+package genaistructbuilder_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
+	"github.com/darwishdev/genaistructbuilder"
 	"github.com/darwishdev/genaistructbuilder/generator"
 	genai "google.golang.org/genai"
 )
@@ -58,25 +60,26 @@ func CheckOutput(t *testing.T, output JobSearchOutput, testName string) {
 	}
 }
 
-// --- Main Test Function ---
+// --- Shared Generator Cases ---
 
-func TestGenAiStructBuilder_Build_AllRealGenerators(t *testing.T) {
-	testSchema := getJobSearchOutputSchema() // Get the global schema
+type generatorCase struct {
+	Name      string
+	Generator genaistructbuilder.Generator[JobSearchOutput]
+}
 
-	// 1. Setup: Inject the mock function into the builder
-	// mockGenerateContent := GenerateContentFunc(StructuredMockGenerateContentFunc)
-	builder := NewStructBuilder[JobSearchOutput](mockGenerateContent)
-	testModel := "gemini-test-mock"
+// newGeneratorCases returns one configured instance of every generator in the generator package.
+func newGeneratorCases(t *testing.T) []generatorCase {
+	t.Helper()
+	testSchema, err := json.Marshal(getJobSearchOutputSchema())
+	if err != nil {
+		t.Fatalf("❌ failed to marshal test schema: %v", err)
+	}
 
 	// Define the common input for Relation and File
 	inputJSON := `{"data": "some job data"}`
 	inputFileBytes := []byte("PDF content")
 
-	// --- Comprehensive Test Cases Array ---
-	tests := []struct {
-		Name      string
-		Generator Generator[JobSearchOutput]
-	}{
+	return []generatorCase{
 		{
 			Name: "1. PromptGenerator_Injection_Test",
 			Generator: &generator.PromptGenerator[JobSearchOutput]{
@@ -107,9 +110,18 @@ func TestGenAiStructBuilder_Build_AllRealGenerators(t *testing.T) {
 			},
 		},
 	}
+}
+
+// --- Main Test Function ---
+
+func TestGenAiStructBuilder_Build_AllRealGenerators(t *testing.T) {
+	// 1. Setup: Inject the mock function into the builder
+	// mockGenerateContent := GenerateContentFunc(StructuredMockGenerateContentFunc)
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mockGenerateContent)
+	testModel := "gemini-test-mock"
 
 	// --- Execute All Test Cases ---
-	for _, tc := range tests {
+	for _, tc := range newGeneratorCases(t) {
 		t.Run(tc.Name, func(t *testing.T) {
 			var output JobSearchOutput
 
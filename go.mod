@@ -4,7 +4,10 @@ go 1.24.0
 
 toolchain go1.24.9
 
-require google.golang.org/genai v1.32.0
+require (
+	google.golang.org/genai v1.32.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	cloud.google.com/go v0.116.0 // indirect
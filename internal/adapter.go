@@ -2,94 +2,13 @@ package internal
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"reflect"
 	"strings"
 
+	"github.com/darwishdev/genaistructbuilder"
 	genai "google.golang.org/genai"
 )
 
-type Type string
-
-const (
-	TypeString  Type = "STRING"
-	TypeInteger Type = "INTEGER"
-	TypeNumber  Type = "NUMBER"
-	TypeBoolean Type = "BOOLEAN"
-	TypeObject  Type = "OBJECT"
-	TypeArray   Type = "ARRAY"
-)
-
-func BuildSchemaFromJson(v []byte) (*genai.Schema, error) {
-	var genSchema genai.Schema
-	err := json.Unmarshal(v, &genSchema)
-	if err != nil {
-		return nil, fmt.Errorf("❌ getting schema from json failed: %w", err)
-	}
-	return &genSchema, nil
-}
-func BuildSchema(v any) *genai.Schema {
-	t := reflect.TypeOf(v)
-	if t.Kind() == reflect.Pointer {
-		t = t.Elem()
-	}
-	return buildSchemaFromType(t)
-}
-func buildSchemaFromType(t reflect.Type) *genai.Schema {
-	s := &genai.Schema{}
-
-	switch t.Kind() {
-	case reflect.Struct:
-		s.Type = genai.TypeObject
-		s.Properties = map[string]*genai.Schema{}
-
-		for i := 0; i < t.NumField(); i++ {
-			f := t.Field(i)
-			if f.PkgPath != "" { // skip unexported
-				continue
-			}
-
-			jsonTag := f.Tag.Get("json")
-			fieldName := strings.Split(jsonTag, ",")[0]
-			if fieldName == "" {
-				fieldName = f.Name
-			}
-
-			fieldSchema := buildSchemaFromType(baseType(f.Type))
-			s.Properties[fieldName] = fieldSchema
-			s.Required = append(s.Required, fieldName)
-		}
-
-	case reflect.Slice, reflect.Array:
-		s.Type = genai.TypeArray
-		s.Items = buildSchemaFromType(baseType(t.Elem()))
-
-	case reflect.String:
-		s.Type = genai.TypeString
-
-	case reflect.Bool:
-		s.Type = genai.TypeBoolean
-
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		s.Type = genai.TypeInteger
-
-	case reflect.Float32, reflect.Float64:
-		s.Type = genai.TypeNumber
-
-	default:
-		s.Type = genai.TypeString
-	}
-
-	return s
-}
-
-func baseType(t reflect.Type) reflect.Type {
-	for t.Kind() == reflect.Pointer {
-		t = t.Elem()
-	}
-	return t
-}
 func float32Ptr(v float32) *float32 { return &v }
 func FileAdapter(
 	ctx context.Context,
@@ -112,5 +31,5 @@ func FileAdapter(
 	}
 
 	// --- Case 3: Unhandled MIME Type ---
-	return "", nil, fmt.Errorf("unsupported file MIME type for adapter: %s", mimeType)
+	return "", nil, fmt.Errorf("%w: %s", genaistructbuilder.ErrUnsupportedMIMEType, mimeType)
 }
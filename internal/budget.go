@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"context"
+	"sort"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/internal/buildctx"
+	genai "google.golang.org/genai"
+)
+
+// PackContents builds the request contents like BuildContents, dropping
+// examples that do not fit the token budget carried by ctx. Instructions are
+// counted as an extra user turn since they are sent as the system instruction.
+// The request is counted once and every example on its own, so a CountTokens
+// backed counter costs one call for the request plus one per measured example.
+func PackContents(ctx context.Context, model, instructions string, layout ExampleLayout, request []*genai.Part, examples []Example) ([]*genai.Content, error) {
+	budget, _ := buildctx.Value[genaistructbuilder.TokenBudget](ctx)
+	if budget.MaxInputTokens <= 0 {
+		return BuildContents(layout, request, examples), nil
+	}
+	counter := budget.Counter
+	if counter == nil {
+		counter = genaistructbuilder.EstimateTokens
+	}
+
+	contents := BuildContents(layout, request, nil)
+	if instructions != "" {
+		contents = append([]*genai.Content{genai.NewContentFromText(instructions, genai.RoleUser)}, contents...)
+	}
+	base, err := counter(ctx, model, contents)
+	if err != nil {
+		return nil, err
+	}
+	if base > budget.MaxInputTokens {
+		return nil, &genaistructbuilder.BudgetError{Model: model, InputTokens: base, MaxInputTokens: budget.MaxInputTokens}
+	}
+
+	order := make([]int, len(examples))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return examples[order[a]].Priority < examples[order[b]].Priority })
+
+	report := genaistructbuilder.BudgetReport{Model: model, MaxInputTokens: budget.MaxInputTokens, InputTokens: base}
+	kept := make([]bool, len(examples))
+	full := false
+	for _, index := range order {
+		example := examples[index]
+		dropped := genaistructbuilder.DroppedExample{Category: example.Category, Input: example.Input}
+		if !full {
+			tokens, err := counter(ctx, model, exampleContents(layout, example))
+			if err != nil {
+				return nil, err
+			}
+			dropped.Tokens = tokens
+			if report.InputTokens+dropped.Tokens <= budget.MaxInputTokens {
+				report.InputTokens += dropped.Tokens
+				kept[index] = true
+				continue
+			}
+			full = true
+		}
+		report.Dropped = append(report.Dropped, dropped)
+	}
+
+	packed := make([]Example, 0, len(examples)-len(report.Dropped))
+	for index, example := range examples {
+		if kept[index] {
+			packed = append(packed, example)
+		}
+	}
+	report.Kept = len(packed)
+
+	LoggerFromContext(ctx).InfoContext(ctx, "packed examples into token budget",
+		"model", model, "kept", report.Kept, "dropped", len(report.Dropped),
+		"input_tokens", report.InputTokens, "max_input_tokens", budget.MaxInputTokens)
+	if budget.OnReport != nil {
+		budget.OnReport(report)
+	}
+	return BuildContents(layout, request, packed), nil
+}
@@ -0,0 +1,168 @@
+// Package buildctx carries the builder settings and the result collector
+// through the context handed to generators. It imports nothing from this
+// module, so both the root package and internal can depend on it.
+package buildctx
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+type key[T any] struct{}
+
+// With returns a copy of ctx carrying value, keyed by its type.
+func With[T any](ctx context.Context, value T) context.Context {
+	return context.WithValue(ctx, key[T]{}, value)
+}
+
+// Value returns the value of type T carried by ctx.
+func Value[T any](ctx context.Context) (T, bool) {
+	value, ok := ctx.Value(key[T]{}).(T)
+	return value, ok
+}
+
+// MaxRepairs is how many repair re-prompts a build allows.
+type MaxRepairs int
+
+// CacheablePrefix marks the static start of a request: the first Contents
+// contents and the last Parts parts of the request turn that follows them,
+// which hold flat examples. Repair turns appended later are not cached.
+type CacheablePrefix struct {
+	Contents int
+	Parts    int
+}
+
+// Pending holds work deferred until the output of one model call is accepted,
+// such as storing the response in a cache. Defer reports false on a nil
+// Pending, so callers outside a build run the work at once.
+type Pending struct {
+	mu    sync.Mutex
+	funcs []func()
+}
+
+// PendingFrom returns the pending set carried by ctx, or nil.
+func PendingFrom(ctx context.Context) *Pending {
+	p, _ := Value[*Pending](ctx)
+	return p
+}
+
+// Defer adds fn to run on Commit.
+func (p *Pending) Defer(fn func()) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.funcs = append(p.funcs, fn)
+	return true
+}
+
+// Commit runs the deferred work once the output was accepted.
+func (p *Pending) Commit() {
+	p.mu.Lock()
+	funcs := p.funcs
+	p.funcs = nil
+	p.mu.Unlock()
+	for _, fn := range funcs {
+		fn()
+	}
+}
+
+// Usage is the token usage summed by a Collector.
+type Usage struct {
+	PromptTokens    int
+	CandidateTokens int
+	CachedTokens    int
+	ThoughtTokens   int
+	TotalTokens     int
+}
+
+// Summary is what a Collector gathered during one build.
+type Summary struct {
+	Raw          string
+	Usage        Usage
+	ModelVersion string
+	FinishReason genai.FinishReason
+	Attempts     int
+	CacheHits    int
+	RequestID    string
+}
+
+// Collector gathers response metadata while a generator runs. Every method is
+// a no-op on a nil collector.
+type Collector struct {
+	mu      sync.Mutex
+	summary Summary
+}
+
+// CollectorFrom returns the collector carried by ctx, or nil.
+func CollectorFrom(ctx context.Context) *Collector {
+	c, _ := Value[*Collector](ctx)
+	return c
+}
+
+// RecordAttempt counts one call to the model.
+func (c *Collector) RecordAttempt() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.summary.Attempts++
+}
+
+// RecordResponse adds the usage of resp and keeps its metadata.
+func (c *Collector) RecordResponse(resp *genai.GenerateContentResponse) {
+	if c == nil || resp == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if usage := resp.UsageMetadata; usage != nil {
+		c.summary.Usage.PromptTokens += int(usage.PromptTokenCount)
+		c.summary.Usage.CandidateTokens += int(usage.CandidatesTokenCount)
+		c.summary.Usage.CachedTokens += int(usage.CachedContentTokenCount)
+		c.summary.Usage.ThoughtTokens += int(usage.ThoughtsTokenCount)
+		c.summary.Usage.TotalTokens += int(usage.TotalTokenCount)
+	}
+	c.summary.ModelVersion = resp.ModelVersion
+	c.summary.RequestID = resp.ResponseID
+	c.summary.FinishReason = ""
+	if len(resp.Candidates) > 0 && resp.Candidates[0] != nil {
+		c.summary.FinishReason = resp.Candidates[0].FinishReason
+	}
+}
+
+// RecordCacheHit records resp, served from a response cache, as an attempt.
+func (c *Collector) RecordCacheHit(resp *genai.GenerateContentResponse) {
+	if c == nil {
+		return
+	}
+	c.RecordAttempt()
+	c.RecordResponse(resp)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.summary.CacheHits++
+}
+
+// RecordRaw keeps the text output of the last response.
+func (c *Collector) RecordRaw(raw string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.summary.Raw = raw
+}
+
+// Summary returns what the collector gathered so far.
+func (c *Collector) Summary() Summary {
+	if c == nil {
+		return Summary{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.summary
+}
@@ -3,88 +3,228 @@ package internal
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/internal/buildctx"
 	genai "google.golang.org/genai"
 )
 
 const ResponseMIMEType = "application/json"
 
+var noopLogger = &genaistructbuilder.Logger{Logger: slog.New(slog.DiscardHandler)}
+
+// LoggerFromContext returns the builder logger carried by ctx, or a no-op logger.
+func LoggerFromContext(ctx context.Context) *genaistructbuilder.Logger {
+	if logger, ok := buildctx.Value[*genaistructbuilder.Logger](ctx); ok && logger != nil {
+		return logger
+	}
+	return noopLogger
+}
+
+// CallOptions tunes how ExecuteLLMCall treats the model output.
+type CallOptions struct {
+	// ValidateOutput checks the output against config.ResponseSchema before decoding it.
+	ValidateOutput bool
+	// StaticParts is how many trailing parts of the request turn are flat
+	// examples, which a context cache may send ahead of the request as cached.
+	StaticParts int
+}
+
 func ExecuteLLMCall[T any](
 	ctx context.Context,
 	generateContent genaistructbuilder.GenerateContentFunc,
 	model string,
 	content []*genai.Content,
 	config *genai.GenerateContentConfig,
+	opts CallOptions,
 	output *T,
 ) error {
-	fmt.Println("=== LLM REQUEST CONTENT ===")
-	fmt.Printf("Model: %s\n", model)
-
-	// Print content with better formatting
-	for i, c := range content {
-		fmt.Printf("Content [%d]:\n", i)
-		for j, part := range c.Parts {
-			if part.Text != "" {
-				fmt.Printf("  Part [%d] (Text):\n", j)
-				fmt.Printf("    %s\n", part.Text)
-			}
+	logger := LoggerFromContext(ctx)
+	n, _ := buildctx.Value[buildctx.MaxRepairs](ctx)
+	maxRepairs := int(n)
+	// Everything before the request turn is static, and so are the flat
+	// examples closing it; repair turns come after it.
+	ctx = buildctx.With(ctx, buildctx.CacheablePrefix{Contents: len(content) - 1, Parts: opts.StaticParts})
+	var validationSchema *genai.Schema
+	if opts.ValidateOutput && config != nil {
+		validationSchema = config.ResponseSchema
+	}
+	var attempts []genaistructbuilder.RepairAttempt
+	for {
+		// Writes to a response cache wait until the output decodes, so a
+		// rejected output is not served again.
+		pending := &buildctx.Pending{}
+		raw, err := generateRaw(buildctx.With(ctx, pending), logger, generateContent, model, content, config)
+		if err != nil {
+			return err
+		}
+		decodeErr := decodeOutput(raw, validationSchema, output)
+		if decodeErr == nil {
+			pending.Commit()
+			return nil
+		}
+		logger.ErrorContext(ctx, "invalid model output", "model", model, "attempt", len(attempts)+1, "error", logger.Err(decodeErr), "response", logger.Body(raw))
+		attempts = append(attempts, genaistructbuilder.RepairAttempt{Raw: raw, Err: decodeErr})
+		if maxRepairs == 0 {
+			return decodeErr
+		}
+		if len(attempts) > maxRepairs {
+			return &genaistructbuilder.RepairError{Attempts: attempts}
 		}
+		content = appendRepairTurns(content, raw, decodeErr)
 	}
+}
 
-	// Print config as JSON for better readability
-	if config != nil {
-		configJSON, _ := json.MarshalIndent(config, "  ", "  ")
-		fmt.Printf("Generation Config:\n  %s\n", string(configJSON))
+func generateRaw(
+	ctx context.Context,
+	logger *genaistructbuilder.Logger,
+	generateContent genaistructbuilder.GenerateContentFunc,
+	model string,
+	content []*genai.Content,
+	config *genai.GenerateContentConfig,
+) (string, error) {
+	logRequest(ctx, logger, model, content, config)
+	if err := ctx.Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", fmt.Errorf("%w before calling model: %w", genaistructbuilder.ErrDeadlineExceeded, err)
+		}
+		return "", fmt.Errorf("%w before calling model: %w", genaistructbuilder.ErrCanceled, err)
 	}
-	fmt.Println("===========================")
+	start := time.Now()
 	resp, err := generateContent(ctx, model, content, config)
+	duration := time.Since(start)
 	if err != nil {
-		return fmt.Errorf("❌ error generating structured relation response: %w", err)
+		logger.ErrorContext(ctx, "llm call failed", "model", model, "duration", duration, "error", err)
+		return "", genaistructbuilder.NewProviderError(model, err)
+	}
+	logger.InfoContext(ctx, "llm call completed", "model", model, "duration", duration)
+	raw, err := responseText(resp)
+	var finishErr *genaistructbuilder.FinishError
+	if err == nil {
+		buildctx.CollectorFrom(ctx).RecordRaw(raw)
+	} else if errors.As(err, &finishErr) {
+		buildctx.CollectorFrom(ctx).RecordRaw(finishErr.Raw)
 	}
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return fmt.Errorf("❌ no response received from model")
+	if err != nil {
+		logger.ErrorContext(ctx, "llm returned no usable output", "model", model, "error", err)
+		return "", err
 	}
-	part := resp.Candidates[0].Content.Parts[0]
-	raw := strings.TrimSpace(part.Text)
+	logger.DebugContext(ctx, "llm raw response", "model", model, "response", logger.Body(raw))
+	return raw, nil
+}
 
-	fmt.Println("Raw Response")
-	fmt.Println(raw)
-	if err := json.Unmarshal([]byte(raw), output); err != nil {
-		return fmt.Errorf("❌ failed to unmarshal model output: %w\nRaw output: %s", err, raw)
+// responseText returns the text of the first candidate, joining its text parts
+// and skipping thought parts. A blocked prompt or response, a finish reason
+// other than STOP and a candidate without text are reported as typed errors.
+func responseText(resp *genai.GenerateContentResponse) (string, error) {
+	if resp == nil {
+		return "", genaistructbuilder.ErrNoCandidates
 	}
-	fmt.Println("Raw Response")
-	fmt.Println(raw)
-	return nil
+	if feedback := resp.PromptFeedback; feedback != nil && feedback.BlockReason != "" {
+		return "", &genaistructbuilder.BlockedError{
+			BlockReason:        feedback.BlockReason,
+			BlockReasonMessage: feedback.BlockReasonMessage,
+			SafetyRatings:      feedback.SafetyRatings,
+		}
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0] == nil {
+		return "", genaistructbuilder.ErrNoCandidates
+	}
+	candidate := resp.Candidates[0]
+
+	var text strings.Builder
+	if candidate.Content != nil {
+		for _, part := range candidate.Content.Parts {
+			if part != nil && !part.Thought {
+				text.WriteString(part.Text)
+			}
+		}
+	}
+	raw := strings.TrimSpace(text.String())
+
+	switch candidate.FinishReason {
+	case "", genai.FinishReasonUnspecified, genai.FinishReasonStop:
+	case genai.FinishReasonSafety, genai.FinishReasonRecitation, genai.FinishReasonBlocklist,
+		genai.FinishReasonProhibitedContent, genai.FinishReasonSPII,
+		genai.FinishReasonImageSafety, genai.FinishReasonImageProhibitedContent:
+		return "", &genaistructbuilder.BlockedError{
+			FinishReason:  candidate.FinishReason,
+			FinishMessage: candidate.FinishMessage,
+			SafetyRatings: candidate.SafetyRatings,
+		}
+	default:
+		return "", &genaistructbuilder.FinishError{
+			FinishReason:  candidate.FinishReason,
+			FinishMessage: candidate.FinishMessage,
+			Raw:           raw,
+		}
+	}
+	if raw == "" {
+		return "", genaistructbuilder.ErrNoCandidates
+	}
+	return raw, nil
 }
-func _appendRelationExamples[T any](parts *[]*genai.Part, examples []genaistructbuilder.RelationExample[T]) {
-	for _, example := range examples {
-		exValue := example.Response
-		exampleJSON, _ := json.MarshalIndent(exValue, "", "  ")
 
-		*parts = append(*parts, &genai.Part{
-			Text: fmt.Sprintf("Example Input JSON: %s\nExpected JSON: %s", example.RelationRecordJSON, exampleJSON),
-		})
+// decodeOutput only writes to output once raw decoded cleanly and, when schema
+// is set, satisfied it. Failures are reported as *genaistructbuilder.DecodeError.
+func decodeOutput[T any](raw string, schema *genai.Schema, output *T) error {
+	if schema != nil {
+		if err := ValidateJSON(schema, []byte(raw)); err != nil {
+			return &genaistructbuilder.DecodeError{Raw: raw, Err: err}
+		}
+	}
+	var decoded T
+	if err := json.Unmarshal(ObjectMapsJSON(reflect.TypeFor[T](), []byte(raw)), &decoded); err != nil {
+		return &genaistructbuilder.DecodeError{Raw: raw, Err: fmt.Errorf("failed to unmarshal model output: %w", err)}
 	}
+	*output = decoded
+	return nil
+}
+
+// appendRepairTurns returns a copy of content extended with the rejected model
+// output and a user turn asking for a corrected answer.
+func appendRepairTurns(content []*genai.Content, raw string, reason error) []*genai.Content {
+	repaired := make([]*genai.Content, 0, len(content)+2)
+	repaired = append(repaired, content...)
+	return append(repaired,
+		genai.NewContentFromText(raw, genai.RoleModel),
+		genai.NewContentFromText(fmt.Sprintf(
+			"Your previous output was invalid because: %v\nReturn only the corrected JSON that satisfies the required schema.",
+			reason,
+		), genai.RoleUser),
+	)
 }
-func _appendExamples[T any](parts *[]*genai.Part, examples []genaistructbuilder.PromptExample[T]) {
-	for _, example := range examples {
-		exValue := example.Response
-		exampleJSON, _ := json.MarshalIndent(exValue, "", "  ")
 
-		*parts = append(*parts, &genai.Part{
-			Text: fmt.Sprintf("Example prompt: %s\nExpected JSON: %s", example.Prompt, exampleJSON),
-		})
+func logRequest(ctx context.Context, logger *genaistructbuilder.Logger, model string, content []*genai.Content, config *genai.GenerateContentConfig) {
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	for i, c := range content {
+		for j, part := range c.Parts {
+			if part.Text != "" {
+				logger.DebugContext(ctx, "llm request part", "model", model, "content", i, "part", j, "text", logger.Body(part.Text))
+			}
+		}
+	}
+	if config != nil {
+		configJSON, _ := json.Marshal(config)
+		logger.DebugContext(ctx, "llm request config", "model", model, "config", logger.Body(string(configJSON)))
 	}
 }
 
+// GenerateConfig builds the request config. Options left unset fall back to
+// the generation defaults carried by ctx; temperature finally defaults to 0.
 func GenerateConfig(
 	ctx context.Context,
 	instructions string,
 	schema *genai.Schema,
-	temprature float32,
+	opts genaistructbuilder.GenerationOptions,
 ) *genai.GenerateContentConfig {
 	instructionsContent := &genai.Content{
 		Parts: []*genai.Part{{Text: instructions}},
@@ -93,26 +233,12 @@ func GenerateConfig(
 		SystemInstruction: instructionsContent,
 		ResponseMIMEType:  ResponseMIMEType,
 		ResponseSchema:    schema,
-		Temperature:       float32Ptr(temprature),
 	}
-	return config
-}
-
-func RelationExampleHandler[T any](parts []*genai.Part, examples []genaistructbuilder.RelationExample[T], categorizedExamples map[string][]genaistructbuilder.RelationExample[T]) {
-	_appendRelationExamples(&parts, examples)
-	for category, exampleSlice := range categorizedExamples {
-		parts = append(parts, &genai.Part{
-			Text: fmt.Sprintf("\n--- Categorized Example Group For Category :%s ---\n", category),
-		})
-		_appendRelationExamples(&parts, exampleSlice)
-	}
-}
-func ExamplesHandler[T any](parts []*genai.Part, examples []genaistructbuilder.PromptExample[T], categorizedExamples map[string][]genaistructbuilder.PromptExample[T]) {
-	_appendExamples(&parts, examples)
-	for category, exampleSlice := range categorizedExamples {
-		parts = append(parts, &genai.Part{
-			Text: fmt.Sprintf("\n--- Categorized Example Group For Category :%s ---\n", category),
-		})
-		_appendExamples(&parts, exampleSlice)
+	defaults, _ := buildctx.Value[genaistructbuilder.GenerationOptions](ctx)
+	opts = opts.WithDefaults(defaults)
+	if opts.Temperature == nil {
+		opts.Temperature = float32Ptr(0)
 	}
+	opts.Apply(config)
+	return config
 }
@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/darwishdev/genaistructbuilder"
+	genai "google.golang.org/genai"
+)
+
+// Example is a few-shot example reduced to its input text and expected output.
+type Example struct {
+	Category            string
+	CategoryDescription string
+	Input               string
+	Response            any
+	// Priority orders examples for token budget packing; lower goes first.
+	Priority int
+}
+
+// ExampleLayout describes how a generator phrases its examples.
+type ExampleLayout struct {
+	Format genaistructbuilder.ExampleFormat
+	// InputLabel prefixes the example input in the flat format.
+	InputLabel string
+	// RenderInput phrases an example input as a user turn, matching the real
+	// request. Nil sends the input unchanged.
+	RenderInput func(input string) string
+	// Schema is the response schema; example responses are shaped to match it.
+	Schema *genai.Schema
+}
+
+// PromptExamples flattens plain and categorized prompt examples, plain ones
+// first, then each category in the order given by OrderCategories.
+func PromptExamples[T any](examples []genaistructbuilder.PromptExample[T], categorizedExamples map[string][]genaistructbuilder.PromptExample[T], categories []genaistructbuilder.ExampleCategory) []Example {
+	var out []Example
+	for _, example := range examples {
+		out = append(out, Example{Input: example.Prompt, Response: example.Response})
+	}
+	for _, category := range OrderCategories(categorizedExamples, categories) {
+		for _, example := range categorizedExamples[category.Name] {
+			out = append(out, Example{Category: category.Name, CategoryDescription: category.Description, Input: example.Prompt, Response: example.Response})
+		}
+	}
+	return out
+}
+
+// RelationExamples flattens plain and categorized relation examples, plain
+// ones first, then each category in the order given by OrderCategories.
+func RelationExamples[T any](examples []genaistructbuilder.RelationExample[T], categorizedExamples map[string][]genaistructbuilder.RelationExample[T], categories []genaistructbuilder.ExampleCategory) []Example {
+	var out []Example
+	for _, example := range examples {
+		out = append(out, Example{Input: example.RelationRecordJSON, Response: example.Response})
+	}
+	for _, category := range OrderCategories(categorizedExamples, categories) {
+		for _, example := range categorizedExamples[category.Name] {
+			out = append(out, Example{Category: category.Name, CategoryDescription: category.Description, Input: example.RelationRecordJSON, Response: example.Response})
+		}
+	}
+	return out
+}
+
+// OrderCategories returns the keys of categorized in a stable order: the
+// categories listed in categories first, in that order, then the remaining
+// keys sorted. Listed categories without examples are skipped.
+func OrderCategories[E any](categorized map[string][]E, categories []genaistructbuilder.ExampleCategory) []genaistructbuilder.ExampleCategory {
+	ordered := make([]genaistructbuilder.ExampleCategory, 0, len(categorized))
+	listed := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		if _, ok := categorized[category.Name]; ok && !listed[category.Name] {
+			ordered = append(ordered, category)
+			listed[category.Name] = true
+		}
+	}
+	var rest []string
+	for name := range categorized {
+		if !listed[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		ordered = append(ordered, genaistructbuilder.ExampleCategory{Name: name})
+	}
+	return ordered
+}
+
+// SelectExamples keeps the examples chosen by selector for input, in their
+// original order, with the selector ranking as their Priority. A nil selector
+// keeps every example.
+func SelectExamples(ctx context.Context, selector genaistructbuilder.ExampleSelector, input string, examples []Example) ([]Example, error) {
+	if selector == nil || len(examples) == 0 {
+		return examples, nil
+	}
+	candidates := make([]genaistructbuilder.ExampleCandidate, len(examples))
+	for i, example := range examples {
+		candidates[i] = genaistructbuilder.ExampleCandidate{Category: example.Category, Input: example.Input}
+	}
+	indexes, err := selector.SelectExamples(ctx, input, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", genaistructbuilder.ErrExampleSelection, err)
+	}
+	rank := make(map[int]int, len(indexes))
+	for r, index := range indexes {
+		if _, seen := rank[index]; !seen && index >= 0 && index < len(examples) {
+			rank[index] = r
+		}
+	}
+	selected := make([]Example, 0, len(rank))
+	for index, example := range examples {
+		if r, ok := rank[index]; ok {
+			example.Priority = r
+			selected = append(selected, example)
+		}
+	}
+	LoggerFromContext(ctx).DebugContext(ctx, "selected examples", "selected", len(selected), "available", len(examples))
+	return selected, nil
+}
+
+// BuildContents assembles the request contents. With ExampleFormatTurns every
+// example becomes a user turn and a model turn ahead of the request, which is
+// sent as the final user turn. With ExampleFormatFlat the examples are appended
+// to the request parts of a single user turn.
+func BuildContents(layout ExampleLayout, request []*genai.Part, examples []Example) []*genai.Content {
+	if layout.Format == genaistructbuilder.ExampleFormatFlat {
+		parts := append([]*genai.Part{}, request...)
+		category := ""
+		for _, example := range examples {
+			parts = append(parts, flatExampleParts(layout, example, category)...)
+			category = example.Category
+		}
+		return []*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)}
+	}
+
+	contents := make([]*genai.Content, 0, 2*len(examples)+1)
+	for _, example := range examples {
+		contents = append(contents, exampleTurns(layout, example)...)
+	}
+	return append(contents, genai.NewContentFromParts(request, genai.RoleUser))
+}
+
+// StaticParts returns how many trailing parts of the request turn built by
+// BuildContents are flat examples rather than the request itself.
+func StaticParts(contents []*genai.Content, request []*genai.Part) int {
+	if len(contents) == 0 {
+		return 0
+	}
+	return max(len(contents[len(contents)-1].Parts)-len(request), 0)
+}
+
+// exampleContents renders example on its own the way BuildContents sends it,
+// so it can be measured without the rest of the request. A flat example
+// carries its category header even when it shares it with the previous one.
+func exampleContents(layout ExampleLayout, example Example) []*genai.Content {
+	if layout.Format == genaistructbuilder.ExampleFormatFlat {
+		return []*genai.Content{genai.NewContentFromParts(flatExampleParts(layout, example, ""), genai.RoleUser)}
+	}
+	return exampleTurns(layout, example)
+}
+
+// flatExampleParts renders example as text parts, opening with a category
+// header when its category differs from the previous example's.
+func flatExampleParts(layout ExampleLayout, example Example, previousCategory string) []*genai.Part {
+	var parts []*genai.Part
+	if example.Category != previousCategory {
+		header := fmt.Sprintf("\n--- Categorized Example Group For Category :%s ---\n", example.Category)
+		if example.CategoryDescription != "" {
+			header += fmt.Sprintf("Applies when: %s\n", example.CategoryDescription)
+		}
+		parts = append(parts, &genai.Part{Text: header})
+	}
+	return append(parts, &genai.Part{
+		Text: fmt.Sprintf("%s: %s\nExpected JSON: %s", layout.InputLabel, example.Input, exampleJSON(example, layout.Schema)),
+	})
+}
+
+// exampleTurns renders example as a user turn and a model turn.
+func exampleTurns(layout ExampleLayout, example Example) []*genai.Content {
+	input := example.Input
+	if layout.RenderInput != nil {
+		input = layout.RenderInput(input)
+	}
+	switch {
+	case example.CategoryDescription != "":
+		input = fmt.Sprintf("Example category: %s (applies when: %s)\n%s", example.Category, example.CategoryDescription, input)
+	case example.Category != "":
+		input = fmt.Sprintf("Example category: %s\n%s", example.Category, input)
+	}
+	return []*genai.Content{
+		genai.NewContentFromText(input, genai.RoleUser),
+		genai.NewContentFromText(exampleJSON(example, layout.Schema), genai.RoleModel),
+	}
+}
+
+// exampleJSON marshals the example response in the shape the schema asks the
+// model for, so maps are written as key/value arrays when the schema says so.
+func exampleJSON(example Example, schema *genai.Schema) string {
+	out, _ := json.Marshal(example.Response)
+	out = KeyValueMapsJSON(reflect.TypeOf(example.Response), schema, out)
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out, "", "  "); err != nil {
+		return string(out)
+	}
+	return indented.String()
+}
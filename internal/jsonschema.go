@@ -0,0 +1,514 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/darwishdev/genaistructbuilder"
+	genai "google.golang.org/genai"
+)
+
+// SchemaWarning reports a JSON Schema keyword that could not be represented
+// exactly by genai.Schema. Path is a JSON pointer into the source document.
+type SchemaWarning struct {
+	Path    string
+	Message string
+}
+
+func (w SchemaWarning) String() string {
+	path := w.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + w.Message
+}
+
+// ignoredKeywords carry no meaning for generation and are dropped silently.
+var ignoredKeywords = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "$anchor": true, "$defs": true,
+	"definitions": true, "readOnly": true, "writeOnly": true, "deprecated": true,
+	"contentMediaType": true, "contentEncoding": true,
+}
+
+// ConvertJSONSchema converts a JSON Schema (draft 2020-12 and earlier, or an
+// OpenAPI schema object) into a genai.Schema. Local $ref pointers are resolved,
+// allOf is merged, oneOf/anyOf become AnyOf and null types become Nullable.
+// Documents already written in Gemini's uppercase form convert unchanged.
+// Keywords genai.Schema cannot express are reported as warnings.
+func ConvertJSONSchema(data []byte) (*genai.Schema, []SchemaWarning, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("getting schema from json failed: %w", err)
+	}
+	c := &schemaConverter{root: doc, resolving: map[string]int{}}
+	s, err := c.convert(doc, "")
+	if err != nil {
+		return nil, c.warnings, err
+	}
+	return s, c.warnings, nil
+}
+
+type schemaConverter struct {
+	root      any
+	resolving map[string]int
+	warnings  []SchemaWarning
+}
+
+func (c *schemaConverter) warn(path, format string, args ...any) {
+	c.warnings = append(c.warnings, SchemaWarning{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *schemaConverter) convert(node any, path string) (*genai.Schema, error) {
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			c.warn(path, "false schema cannot be expressed and was treated as any value")
+		}
+		return &genai.Schema{}, nil
+	case map[string]any:
+		if ref, ok := n["$ref"].(string); ok {
+			return c.convertRef(ref, n, path)
+		}
+		return c.convertObject(n, path)
+	}
+	return nil, fmt.Errorf("schema at %q must be an object or a boolean", path)
+}
+
+func (c *schemaConverter) convertRef(ref string, node map[string]any, path string) (*genai.Schema, error) {
+	if c.resolving[ref] >= genaistructbuilder.DefaultMaxRecursionDepth {
+		c.warn(path, "recursive reference %q truncated after %d levels", ref, genaistructbuilder.DefaultMaxRecursionDepth)
+		return &genai.Schema{Nullable: genai.Ptr(true)}, nil
+	}
+	target, err := c.resolvePointer(ref)
+	if err != nil {
+		return nil, fmt.Errorf("schema at %q: %w", path, err)
+	}
+	c.resolving[ref]++
+	s, err := c.convert(target, strings.TrimPrefix(ref, "#"))
+	c.resolving[ref]--
+	if err != nil {
+		return nil, err
+	}
+	siblings := make(map[string]any, len(node))
+	for key, value := range node {
+		if key != "$ref" {
+			siblings[key] = value
+		}
+	}
+	if len(siblings) == 0 {
+		return s, nil
+	}
+	overlay, err := c.convertObject(siblings, path)
+	if err != nil {
+		return nil, err
+	}
+	merged := *s
+	mergeSchema(&merged, overlay, true)
+	return &merged, nil
+}
+
+// resolvePointer resolves a local reference such as #/$defs/Address.
+func (c *schemaConverter) resolvePointer(ref string) (any, error) {
+	fragment, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("only local $ref values are supported, got %q", ref)
+	}
+	fragment, err := url.PathUnescape(fragment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref %q: %w", ref, err)
+	}
+	current := c.root
+	if fragment == "" {
+		return current, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(fragment, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch node := current.(type) {
+		case map[string]any:
+			next, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("unresolved $ref %q", ref)
+			}
+			current = next
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("unresolved $ref %q", ref)
+			}
+			current = node[i]
+		default:
+			return nil, fmt.Errorf("unresolved $ref %q", ref)
+		}
+	}
+	return current, nil
+}
+
+func (c *schemaConverter) convertObject(node map[string]any, path string) (*genai.Schema, error) {
+	s := &genai.Schema{}
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := node[key]
+		keyPath := pointer(path, key)
+		switch key {
+		case "type":
+			c.applyType(s, value, keyPath)
+		case "properties":
+			properties, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("schema at %q: properties must be an object", keyPath)
+			}
+			s.Properties = make(map[string]*genai.Schema, len(properties))
+			names := make([]string, 0, len(properties))
+			for name := range properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				property, err := c.convert(properties[name], pointer(keyPath, name))
+				if err != nil {
+					return nil, err
+				}
+				s.Properties[name] = property
+			}
+		case "required":
+			s.Required = c.stringList(value, keyPath)
+		case "propertyOrdering":
+			s.PropertyOrdering = c.stringList(value, keyPath)
+		case "items":
+			if tuple, ok := value.([]any); ok {
+				c.warn(keyPath, "tuple items are not supported; items accept any of the listed schemas")
+				branches, err := c.convertList(tuple, keyPath)
+				if err != nil {
+					return nil, err
+				}
+				s.Items = &genai.Schema{AnyOf: branches}
+				continue
+			}
+			items, err := c.convert(value, keyPath)
+			if err != nil {
+				return nil, err
+			}
+			s.Items = items
+		case "enum":
+			values, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("schema at %q: enum must be an array", keyPath)
+			}
+			for _, v := range values {
+				c.addEnumValue(s, v, keyPath)
+			}
+		case "const":
+			c.addEnumValue(s, value, keyPath)
+		case "nullable":
+			if b, ok := value.(bool); ok && b {
+				s.Nullable = genai.Ptr(true)
+			}
+		case "description":
+			s.Description, _ = value.(string)
+		case "title":
+			s.Title, _ = value.(string)
+		case "format":
+			s.Format, _ = value.(string)
+		case "pattern":
+			s.Pattern, _ = value.(string)
+		case "default":
+			s.Default = plainJSON(value)
+		case "example":
+			s.Example = plainJSON(value)
+		case "examples":
+			if examples, ok := value.([]any); ok && len(examples) > 0 {
+				s.Example = plainJSON(examples[0])
+			}
+		case "minimum":
+			s.Minimum = c.float(value, keyPath)
+		case "maximum":
+			s.Maximum = c.float(value, keyPath)
+		case "exclusiveMinimum", "exclusiveMaximum":
+			bound := c.float(value, keyPath)
+			if bound == nil {
+				c.warn(keyPath, "boolean %s is not supported", key)
+				continue
+			}
+			c.warn(keyPath, "%s is approximated by an inclusive bound", key)
+			if key == "exclusiveMinimum" {
+				s.Minimum = bound
+			} else {
+				s.Maximum = bound
+			}
+		case "minLength":
+			s.MinLength = c.int(value, keyPath)
+		case "maxLength":
+			s.MaxLength = c.int(value, keyPath)
+		case "minItems":
+			s.MinItems = c.int(value, keyPath)
+		case "maxItems":
+			s.MaxItems = c.int(value, keyPath)
+		case "minProperties":
+			s.MinProperties = c.int(value, keyPath)
+		case "maxProperties":
+			s.MaxProperties = c.int(value, keyPath)
+		case "anyOf", "oneOf", "allOf":
+			// Combinators are applied once the schema's own keywords are set.
+			continue
+		case "additionalProperties":
+			if allowed, ok := value.(bool); ok && !allowed {
+				continue
+			}
+			c.warn(keyPath, "additionalProperties is not supported; extra keys are not described")
+		default:
+			if !ignoredKeywords[key] {
+				c.warn(keyPath, "unsupported keyword %q ignored", key)
+			}
+		}
+	}
+
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		value, ok := node[key]
+		if !ok {
+			continue
+		}
+		keyPath := pointer(path, key)
+		list, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("schema at %q: %s must be an array", keyPath, key)
+		}
+		branches, err := c.convertList(list, keyPath)
+		if err != nil {
+			return nil, err
+		}
+		if key == "allOf" {
+			for _, branch := range branches {
+				mergeSchema(s, branch, false)
+			}
+			continue
+		}
+		c.applyAnyOf(s, branches)
+	}
+
+	if s.Type == "" && len(s.AnyOf) == 0 {
+		switch {
+		case len(s.Properties) > 0 || len(s.Required) > 0:
+			s.Type = genai.TypeObject
+		case s.Items != nil:
+			s.Type = genai.TypeArray
+		}
+	}
+	return s, nil
+}
+
+func (c *schemaConverter) convertList(list []any, path string) ([]*genai.Schema, error) {
+	schemas := make([]*genai.Schema, 0, len(list))
+	for i, item := range list {
+		s, err := c.convert(item, pointer(path, strconv.Itoa(i)))
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, nil
+}
+
+func (c *schemaConverter) applyType(s *genai.Schema, value any, path string) {
+	var types []string
+	switch v := value.(type) {
+	case string:
+		types = []string{v}
+	case []any:
+		types = c.stringList(v, path)
+	default:
+		c.warn(path, "type must be a string or an array of strings")
+		return
+	}
+	var concrete []genai.Type
+	for _, t := range types {
+		if strings.EqualFold(t, "null") {
+			s.Nullable = genai.Ptr(true)
+			continue
+		}
+		concrete = append(concrete, genai.Type(strings.ToUpper(t)))
+	}
+	switch len(concrete) {
+	case 0:
+	case 1:
+		s.Type = concrete[0]
+	default:
+		for _, t := range concrete {
+			s.AnyOf = append(s.AnyOf, &genai.Schema{Type: t})
+		}
+	}
+}
+
+// applyAnyOf folds null branches into Nullable and inlines a single remaining branch.
+func (c *schemaConverter) applyAnyOf(s *genai.Schema, branches []*genai.Schema) {
+	var remaining []*genai.Schema
+	for _, branch := range branches {
+		if branch.Type == "" && branch.Nullable != nil && *branch.Nullable && len(branch.AnyOf) == 0 && len(branch.Properties) == 0 {
+			s.Nullable = genai.Ptr(true)
+			continue
+		}
+		remaining = append(remaining, branch)
+	}
+	if len(remaining) == 1 {
+		mergeSchema(s, remaining[0], false)
+		return
+	}
+	s.AnyOf = append(s.AnyOf, remaining...)
+}
+
+func (c *schemaConverter) addEnumValue(s *genai.Schema, value any, path string) {
+	switch v := value.(type) {
+	case nil:
+		s.Nullable = genai.Ptr(true)
+	case string:
+		s.Enum = append(s.Enum, v)
+	default:
+		c.warn(path, "non-string enum value %v converted to a string", v)
+		s.Enum = append(s.Enum, fmt.Sprint(v))
+	}
+}
+
+func (c *schemaConverter) stringList(value any, path string) []string {
+	list, ok := value.([]any)
+	if !ok {
+		c.warn(path, "expected an array of strings")
+		return nil
+	}
+	strs := make([]string, 0, len(list))
+	for _, item := range list {
+		if str, ok := item.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
+
+func (c *schemaConverter) float(value any, path string) *float64 {
+	number, ok := value.(json.Number)
+	if !ok {
+		return nil
+	}
+	f, err := number.Float64()
+	if err != nil {
+		c.warn(path, "invalid number %s", number)
+		return nil
+	}
+	return &f
+}
+
+func (c *schemaConverter) int(value any, path string) *int64 {
+	f := c.float(value, path)
+	if f == nil {
+		return nil
+	}
+	return genai.Ptr(int64(*f))
+}
+
+// plainJSON turns json.Number values back into float64 so defaults and
+// examples marshal like any other decoded JSON.
+func plainJSON(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = plainJSON(item)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = plainJSON(item)
+		}
+		return out
+	}
+	return value
+}
+
+// mergeSchema folds src into dst. Properties and required names are unioned;
+// other fields are copied when dst leaves them unset, or always when override is set.
+func mergeSchema(dst, src *genai.Schema, override bool) {
+	setString := func(d *string, s string) {
+		if s != "" && (override || *d == "") {
+			*d = s
+		}
+	}
+	setString(&dst.Description, src.Description)
+	setString(&dst.Title, src.Title)
+	setString(&dst.Format, src.Format)
+	setString(&dst.Pattern, src.Pattern)
+	if src.Type != "" && (override || dst.Type == "") {
+		dst.Type = src.Type
+	}
+	if src.Items != nil && (override || dst.Items == nil) {
+		dst.Items = src.Items
+	}
+	if len(src.Enum) > 0 && (override || len(dst.Enum) == 0) {
+		dst.Enum = src.Enum
+	}
+	if len(src.AnyOf) > 0 && (override || len(dst.AnyOf) == 0) {
+		dst.AnyOf = src.AnyOf
+	}
+	if src.Default != nil && (override || dst.Default == nil) {
+		dst.Default = src.Default
+	}
+	if src.Example != nil && (override || dst.Example == nil) {
+		dst.Example = src.Example
+	}
+	for _, bound := range []struct{ d, s **int64 }{
+		{&dst.MinItems, &src.MinItems}, {&dst.MaxItems, &src.MaxItems},
+		{&dst.MinLength, &src.MinLength}, {&dst.MaxLength, &src.MaxLength},
+		{&dst.MinProperties, &src.MinProperties}, {&dst.MaxProperties, &src.MaxProperties},
+	} {
+		if *bound.s != nil && (override || *bound.d == nil) {
+			*bound.d = *bound.s
+		}
+	}
+	for _, bound := range []struct{ d, s **float64 }{{&dst.Minimum, &src.Minimum}, {&dst.Maximum, &src.Maximum}} {
+		if *bound.s != nil && (override || *bound.d == nil) {
+			*bound.d = *bound.s
+		}
+	}
+	if src.Nullable != nil && (override || dst.Nullable == nil) {
+		dst.Nullable = src.Nullable
+	}
+	if len(src.Properties) > 0 {
+		properties := make(map[string]*genai.Schema, len(dst.Properties)+len(src.Properties))
+		for name, property := range dst.Properties {
+			properties[name] = property
+		}
+		for name, property := range src.Properties {
+			if _, exists := properties[name]; !exists || override {
+				properties[name] = property
+			}
+		}
+		dst.Properties = properties
+	}
+	for _, name := range src.Required {
+		if !slices.Contains(dst.Required, name) {
+			dst.Required = append(dst.Required, name)
+		}
+	}
+	for _, name := range src.PropertyOrdering {
+		if !slices.Contains(dst.PropertyOrdering, name) {
+			dst.PropertyOrdering = append(dst.PropertyOrdering, name)
+		}
+	}
+}
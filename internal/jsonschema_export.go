@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	genai "google.golang.org/genai"
+	"gopkg.in/yaml.v3"
+)
+
+// JSONSchemaDialect is the $schema written by ExportJSONSchema.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// ExportJSONSchema renders s as an indented draft 2020-12 JSON Schema document.
+// Types are lowercased, Nullable becomes a "null" type member, Example becomes
+// examples and PropertyOrdering is kept as the propertyOrdering annotation so
+// ConvertJSONSchema restores the original schema.
+func ExportJSONSchema(s *genai.Schema) ([]byte, error) {
+	doc := jsonSchemaObject(s)
+	doc["$schema"] = JSONSchemaDialect
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("exporting json schema failed: %w", err)
+	}
+	return out, nil
+}
+
+// ExportOpenAPIComponents renders schemas as an OpenAPI 3.1 components
+// document in YAML, keyed by component name.
+func ExportOpenAPIComponents(schemas map[string]*genai.Schema) ([]byte, error) {
+	components := make(map[string]any, len(schemas))
+	for name, s := range schemas {
+		components[name] = jsonSchemaObject(s)
+	}
+	out, err := yaml.Marshal(map[string]any{
+		"components": map[string]any{"schemas": components},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("exporting openapi components failed: %w", err)
+	}
+	return out, nil
+}
+
+func jsonSchemaObject(s *genai.Schema) map[string]any {
+	doc := map[string]any{}
+	if s == nil {
+		return doc
+	}
+	nullable := s.Nullable != nil && *s.Nullable
+	switch {
+	case s.Type != "" && nullable:
+		doc["type"] = []string{strings.ToLower(string(s.Type)), "null"}
+	case s.Type != "":
+		doc["type"] = strings.ToLower(string(s.Type))
+	case nullable && len(s.AnyOf) == 0:
+		doc["type"] = "null"
+	}
+	if len(s.AnyOf) > 0 {
+		anyOf := make([]any, 0, len(s.AnyOf)+1)
+		for _, branch := range s.AnyOf {
+			anyOf = append(anyOf, jsonSchemaObject(branch))
+		}
+		if nullable && s.Type == "" {
+			anyOf = append(anyOf, map[string]any{"type": "null"})
+		}
+		doc["anyOf"] = anyOf
+	}
+	if len(s.Properties) > 0 {
+		properties := make(map[string]any, len(s.Properties))
+		for name, property := range s.Properties {
+			properties[name] = jsonSchemaObject(property)
+		}
+		doc["properties"] = properties
+	}
+	if s.Items != nil {
+		doc["items"] = jsonSchemaObject(s.Items)
+	}
+	if len(s.Required) > 0 {
+		doc["required"] = s.Required
+	}
+	if len(s.PropertyOrdering) > 0 {
+		doc["propertyOrdering"] = s.PropertyOrdering
+	}
+	if len(s.Enum) > 0 {
+		doc["enum"] = s.Enum
+	}
+	if s.Example != nil {
+		doc["examples"] = []any{s.Example}
+	}
+	if s.Default != nil {
+		doc["default"] = s.Default
+	}
+	for key, value := range map[string]string{
+		"description": s.Description,
+		"title":       s.Title,
+		"format":      s.Format,
+		"pattern":     s.Pattern,
+	} {
+		if value != "" {
+			doc[key] = value
+		}
+	}
+	for key, value := range map[string]*int64{
+		"minItems":      s.MinItems,
+		"maxItems":      s.MaxItems,
+		"minLength":     s.MinLength,
+		"maxLength":     s.MaxLength,
+		"minProperties": s.MinProperties,
+		"maxProperties": s.MaxProperties,
+	} {
+		if value != nil {
+			doc[key] = *value
+		}
+	}
+	if s.Minimum != nil {
+		doc["minimum"] = *s.Minimum
+	}
+	if s.Maximum != nil {
+		doc["maximum"] = *s.Maximum
+	}
+	return doc
+}
@@ -0,0 +1,183 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	genai "google.golang.org/genai"
+)
+
+// The Gemini API rejects OBJECT schemas without properties, so derived schemas
+// describe a Go map as an array of {"key": ..., "value": ...} pairs. The helpers
+// below convert JSON between that shape and the object shape encoding/json
+// uses for maps.
+
+// keyValueSchema describes a map whose values follow value.
+func keyValueSchema(value *genai.Schema) *genai.Schema {
+	return &genai.Schema{
+		Type:        genai.TypeArray,
+		Description: "Map entries as key/value pairs.",
+		Items: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"key":   {Type: genai.TypeString},
+				"value": value,
+			},
+			PropertyOrdering: []string{"key", "value"},
+			Required:         []string{"key", "value"},
+		},
+	}
+}
+
+// ObjectMapsJSON rewrites the key/value arrays in data that stand for maps of
+// t into JSON objects, so data can be unmarshaled into t. Data is returned
+// unchanged when t holds no maps or data is not valid JSON.
+func ObjectMapsJSON(t reflect.Type, data []byte) []byte {
+	return rewriteMaps(t, data, func(value any) any { return objectMaps(t, value) })
+}
+
+// KeyValueMapsJSON rewrites the objects in data that stand for maps of t into
+// key/value arrays wherever schema expects an array, so values marshaled from
+// t match a derived schema. Data is returned unchanged when t holds no maps or
+// data is not valid JSON.
+func KeyValueMapsJSON(t reflect.Type, schema *genai.Schema, data []byte) []byte {
+	return rewriteMaps(t, data, func(value any) any { return keyValueMaps(t, schema, value) })
+}
+
+func rewriteMaps(t reflect.Type, data []byte, rewrite func(any) any) []byte {
+	if t == nil || !hasMaps(t, map[reflect.Type]bool{}) {
+		return data
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return data
+	}
+	out, err := json.Marshal(rewrite(value))
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// opaqueType reports whether t is encoded by its own rules rather than by
+// walking its fields, so maps inside it are left alone.
+func opaqueType(t reflect.Type) bool {
+	if t == timeType || t == rawMessageType {
+		return true
+	}
+	_, custom := customSchema(t)
+	return custom
+}
+
+func hasMaps(t reflect.Type, seen map[reflect.Type]bool) bool {
+	t = baseType(t)
+	if seen[t] || opaqueType(t) {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Slice, reflect.Array:
+		return hasMaps(t.Elem(), seen)
+	case reflect.Struct:
+		for _, f := range structFields(t) {
+			if hasMaps(f.field.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func objectMaps(t reflect.Type, value any) any {
+	t = baseType(t)
+	if opaqueType(t) {
+		return value
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if object, ok := value.(map[string]any); ok {
+			for _, f := range structFields(t) {
+				if v, ok := object[f.name]; ok {
+					object[f.name] = objectMaps(f.field.Type, v)
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if array, ok := value.([]any); ok {
+			for i, item := range array {
+				array[i] = objectMaps(t.Elem(), item)
+			}
+		}
+	case reflect.Map:
+		switch entries := value.(type) {
+		case []any:
+			object := make(map[string]any, len(entries))
+			for _, entry := range entries {
+				pair, ok := entry.(map[string]any)
+				if !ok {
+					return value
+				}
+				key, ok := pair["key"].(string)
+				if !ok {
+					key = fmt.Sprint(pair["key"])
+				}
+				object[key] = objectMaps(t.Elem(), pair["value"])
+			}
+			return object
+		case map[string]any:
+			for key, v := range entries {
+				entries[key] = objectMaps(t.Elem(), v)
+			}
+		}
+	}
+	return value
+}
+
+func keyValueMaps(t reflect.Type, schema *genai.Schema, value any) any {
+	t = baseType(t)
+	if schema == nil || opaqueType(t) {
+		return value
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if object, ok := value.(map[string]any); ok {
+			for _, f := range structFields(t) {
+				if v, ok := object[f.name]; ok {
+					object[f.name] = keyValueMaps(f.field.Type, schema.Properties[f.name], v)
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if array, ok := value.([]any); ok {
+			for i, item := range array {
+				array[i] = keyValueMaps(t.Elem(), schema.Items, item)
+			}
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok || schema.Type != genai.TypeArray || schema.Items == nil {
+			return value
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		entries := make([]any, 0, len(keys))
+		for _, key := range keys {
+			entries = append(entries, map[string]any{
+				"key":   key,
+				"value": keyValueMaps(t.Elem(), schema.Items.Properties["value"], object[key]),
+			})
+		}
+		return entries
+	}
+	return value
+}
@@ -0,0 +1,472 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/internal/buildctx"
+	genai "google.golang.org/genai"
+)
+
+type Type string
+
+const (
+	TypeString  Type = "STRING"
+	TypeInteger Type = "INTEGER"
+	TypeNumber  Type = "NUMBER"
+	TypeBoolean Type = "BOOLEAN"
+	TypeObject  Type = "OBJECT"
+	TypeArray   Type = "ARRAY"
+)
+
+// BuildSchemaFromJson converts a JSON Schema or Gemini schema document into a
+// genai.Schema, discarding conversion warnings. See ConvertJSONSchema.
+func BuildSchemaFromJson(v []byte) (*genai.Schema, error) {
+	genSchema, _, err := ConvertJSONSchema(v)
+	return genSchema, err
+}
+
+// ResolveSchema parses raw as the response schema, or derives the schema from T
+// when raw is empty. Explicit bytes always take precedence. Conversion warnings
+// are logged through the context logger; failures are *genaistructbuilder.SchemaError.
+func ResolveSchema[T any](ctx context.Context, raw []byte) (*genai.Schema, error) {
+	if len(raw) == 0 {
+		opts, _ := buildctx.Value[genaistructbuilder.SchemaOptions](ctx)
+		return BuildSchemaWithOptions(new(T), opts), nil
+	}
+	genSchema, warnings, err := ConvertJSONSchema(raw)
+	logger := LoggerFromContext(ctx)
+	for _, warning := range warnings {
+		logger.WarnContext(ctx, "schema conversion warning", "path", warning.Path, "warning", warning.Message)
+	}
+	if err != nil {
+		return nil, &genaistructbuilder.SchemaError{Err: err}
+	}
+	return genSchema, nil
+}
+
+// BuildSchema derives a schema from the Go type of v. Struct fields are named
+// after their json tag and described by an optional genai tag:
+//
+//	Level string `json:"level,omitempty" genai:"description=Seniority, as stated,enum=junior|senior,optional"`
+//
+// Supported genai tag keys are description, title, enum (values separated by |),
+// format, pattern, min, max, example and the flags nullable, optional and required.
+// min and max bound string length, array items, object properties or numeric
+// values depending on the field type. Fields are required unless they are
+// tagged optional or use json omitempty; a legacy description tag is honored too.
+//
+// Embedded structs are promoted following encoding/json rules and time.Time
+// becomes a date-time string. Maps become arrays of key/value pairs, since the
+// API requires declared properties on objects; model output in that shape is
+// turned back into maps when it is decoded. Interfaces and json.RawMessage
+// become optional nullable strings. A self-referential type nested deeper than
+// the recursion limit becomes a nullable object the model is told to leave null.
+func BuildSchema(v any) *genai.Schema {
+	return BuildSchemaWithOptions(v, genaistructbuilder.SchemaOptions{})
+}
+
+// BuildSchemaWithOptions is BuildSchema with explicit derivation options.
+func BuildSchemaWithOptions(v any, opts genaistructbuilder.SchemaOptions) *genai.Schema {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return &genai.Schema{}
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	maxDepth := opts.MaxRecursionDepth
+	if maxDepth <= 0 {
+		maxDepth = genaistructbuilder.DefaultMaxRecursionDepth
+	}
+	b := &schemaBuilder{maxDepth: maxDepth, visiting: map[reflect.Type]int{}}
+	if s := b.buildSchemaFromType(t); s != nil {
+		return s
+	}
+	return &genai.Schema{}
+}
+
+var (
+	timeType           = reflect.TypeFor[time.Time]()
+	rawMessageType     = reflect.TypeFor[json.RawMessage]()
+	schemaProviderType = reflect.TypeFor[genaistructbuilder.SchemaProvider]()
+)
+
+// customSchema returns a copy of the schema registered for t or provided by its
+// SchemaProvider implementation, so field tags can be applied without touching
+// the original.
+func customSchema(t reflect.Type) (*genai.Schema, bool) {
+	schema, ok := genaistructbuilder.RegisteredSchema(t)
+	if !ok {
+		schema, ok = providedSchema(t)
+	}
+	if !ok || schema == nil {
+		return nil, false
+	}
+	copied := *schema
+	return &copied, true
+}
+
+func providedSchema(t reflect.Type) (*genai.Schema, bool) {
+	switch {
+	case t.Kind() == reflect.Interface:
+		return nil, false
+	case t.Implements(schemaProviderType):
+		return reflect.Zero(t).Interface().(genaistructbuilder.SchemaProvider).GenAISchema(), true
+	case reflect.PointerTo(t).Implements(schemaProviderType):
+		return reflect.New(t).Interface().(genaistructbuilder.SchemaProvider).GenAISchema(), true
+	}
+	return nil, false
+}
+
+type schemaBuilder struct {
+	maxDepth int
+	visiting map[reflect.Type]int
+}
+
+// buildSchemaFromType returns nil for types that cannot be described, such as
+// a struct without fields; fields of such types are left out.
+func (b *schemaBuilder) buildSchemaFromType(t reflect.Type) *genai.Schema {
+	if custom, ok := customSchema(t); ok {
+		return custom
+	}
+	s := &genai.Schema{}
+
+	switch {
+	case t == timeType:
+		s.Type = genai.TypeString
+		s.Format = "date-time"
+		return s
+	case t == rawMessageType:
+		return anyValueSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if b.visiting[t] >= b.maxDepth {
+			return recursionLimitSchema()
+		}
+		b.visiting[t]++
+		defer func() { b.visiting[t]-- }()
+
+		s.Type = genai.TypeObject
+		s.Properties = map[string]*genai.Schema{}
+		for _, f := range structFields(t) {
+			fieldSchema := b.buildSchemaFromType(baseType(f.field.Type))
+			if fieldSchema == nil {
+				continue
+			}
+			tag := parseFieldTag(f.field.Tag)
+			tag.apply(fieldSchema)
+			s.Properties[f.name] = fieldSchema
+			s.PropertyOrdering = append(s.PropertyOrdering, f.name)
+			if tag.required || !(tag.optional || f.omitempty || isAnyValue(f.field.Type)) {
+				s.Required = append(s.Required, f.name)
+			}
+		}
+		if len(s.Properties) == 0 {
+			return nil
+		}
+
+	case reflect.Map:
+		valueSchema := b.buildSchemaFromType(baseType(t.Elem()))
+		if valueSchema == nil {
+			return nil
+		}
+		return keyValueSchema(valueSchema)
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes byte slices as base64 strings.
+			s.Type = genai.TypeString
+			s.Format = "byte"
+			break
+		}
+		s.Type = genai.TypeArray
+		s.Items = b.buildSchemaFromType(baseType(t.Elem()))
+		if s.Items == nil {
+			return nil
+		}
+
+	case reflect.String:
+		s.Type = genai.TypeString
+
+	case reflect.Bool:
+		s.Type = genai.TypeBoolean
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s.Type = genai.TypeInteger
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		s.Type = genai.TypeInteger
+		s.Minimum = genai.Ptr(0.0)
+
+	case reflect.Float32, reflect.Float64:
+		s.Type = genai.TypeNumber
+
+	case reflect.Interface:
+		return anyValueSchema()
+
+	default:
+		s.Type = genai.TypeString
+	}
+
+	return s
+}
+
+// recursionLimitSchema stands in for a self-referential struct nested past the
+// recursion limit, so the field keeps its name and the model sends null there.
+func recursionLimitSchema() *genai.Schema {
+	return &genai.Schema{Type: genai.TypeObject, Nullable: genai.Ptr(true), Description: "Nesting limit reached; always null."}
+}
+
+// anyValueSchema stands in for interfaces and json.RawMessage. The API has no
+// schema for an arbitrary value, so the model is offered an optional string.
+func anyValueSchema() *genai.Schema {
+	return &genai.Schema{Type: genai.TypeString, Nullable: genai.Ptr(true), Description: "Free-form value."}
+}
+
+func isAnyValue(t reflect.Type) bool {
+	t = baseType(t)
+	if _, custom := customSchema(t); custom {
+		return false
+	}
+	return t == rawMessageType || t.Kind() == reflect.Interface
+}
+
+func baseType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+type structField struct {
+	name      string
+	field     reflect.StructField
+	omitempty bool
+	tagged    bool
+	depth     int
+}
+
+// structFields lists the JSON-visible fields of t in declaration order,
+// promoting the fields of embedded structs the way encoding/json does: the
+// shallowest field wins, a json-tagged field beats an untagged one at the same
+// depth and remaining conflicts drop the name entirely.
+func structFields(t reflect.Type) []structField {
+	var all []structField
+	collectStructFields(t, 0, map[reflect.Type]bool{}, &all)
+
+	byName := map[string][]int{}
+	var order []string
+	for i, f := range all {
+		if _, seen := byName[f.name]; !seen {
+			order = append(order, f.name)
+		}
+		byName[f.name] = append(byName[f.name], i)
+	}
+	fields := make([]structField, 0, len(order))
+	for _, name := range order {
+		if f, ok := dominantField(all, byName[name]); ok {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func collectStructFields(t reflect.Type, depth int, seen map[reflect.Type]bool, out *[]structField) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonTag := f.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		jsonOpts := strings.Split(jsonTag, ",")
+		name := jsonOpts[0]
+		if f.Anonymous {
+			embedded := baseType(f.Type)
+			if name == "" && embedded.Kind() == reflect.Struct {
+				collectStructFields(embedded, depth+1, seen, out)
+				continue
+			}
+			if f.PkgPath != "" && embedded.Kind() != reflect.Struct {
+				continue
+			}
+		} else if f.PkgPath != "" { // skip unexported
+			continue
+		}
+		field := structField{name: name, field: f, tagged: name != "", depth: depth}
+		if field.name == "" {
+			field.name = f.Name
+		}
+		for _, opt := range jsonOpts[1:] {
+			if opt == "omitempty" || opt == "omitzero" {
+				field.omitempty = true
+			}
+		}
+		*out = append(*out, field)
+	}
+}
+
+func dominantField(all []structField, indexes []int) (structField, bool) {
+	best := all[indexes[0]]
+	conflict := false
+	for _, i := range indexes[1:] {
+		f := all[i]
+		switch {
+		case f.depth < best.depth || (f.depth == best.depth && f.tagged && !best.tagged):
+			best, conflict = f, false
+		case f.depth == best.depth && f.tagged == best.tagged:
+			conflict = true
+		}
+	}
+	return best, !conflict
+}
+
+// fieldTag is the parsed form of a genai struct tag.
+type fieldTag struct {
+	description string
+	title       string
+	enum        []string
+	format      string
+	pattern     string
+	example     string
+	min         *float64
+	max         *float64
+	nullable    bool
+	optional    bool
+	required    bool
+}
+
+var (
+	fieldTagKeys  = map[string]bool{"description": true, "title": true, "enum": true, "format": true, "pattern": true, "min": true, "max": true, "example": true}
+	fieldTagFlags = map[string]bool{"nullable": true, "optional": true, "required": true}
+)
+
+func parseFieldTag(structTag reflect.StructTag) fieldTag {
+	tag := fieldTag{description: structTag.Get("description")}
+	for _, item := range splitFieldTag(structTag.Get("genai")) {
+		key, value, hasValue := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if !hasValue {
+			switch key {
+			case "nullable":
+				tag.nullable = true
+			case "optional":
+				tag.optional = true
+			case "required":
+				tag.required = true
+			}
+			continue
+		}
+		switch key {
+		case "description":
+			tag.description = value
+		case "title":
+			tag.title = value
+		case "enum":
+			tag.enum = strings.Split(value, "|")
+		case "format":
+			tag.format = value
+		case "pattern":
+			tag.pattern = value
+		case "example":
+			tag.example = value
+		case "min":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				tag.min = &f
+			}
+		case "max":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				tag.max = &f
+			}
+		}
+	}
+	return tag
+}
+
+// splitFieldTag splits a genai tag on commas that start a known key or flag, so
+// free-text values such as descriptions may contain commas.
+func splitFieldTag(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	var items []string
+	for _, segment := range strings.Split(raw, ",") {
+		key, _, hasValue := strings.Cut(segment, "=")
+		key = strings.TrimSpace(key)
+		startsItem := (hasValue && fieldTagKeys[key]) || (!hasValue && fieldTagFlags[key])
+		if startsItem || len(items) == 0 {
+			items = append(items, segment)
+			continue
+		}
+		items[len(items)-1] += "," + segment
+	}
+	return items
+}
+
+func (tag fieldTag) apply(s *genai.Schema) {
+	if tag.description != "" {
+		s.Description = tag.description
+	}
+	if tag.title != "" {
+		s.Title = tag.title
+	}
+	if len(tag.enum) > 0 {
+		s.Enum = tag.enum
+		if s.Type == genai.TypeString && s.Format == "" {
+			s.Format = "enum"
+		}
+	}
+	if tag.format != "" {
+		s.Format = tag.format
+	}
+	if tag.pattern != "" {
+		s.Pattern = tag.pattern
+	}
+	if tag.nullable {
+		s.Nullable = genai.Ptr(true)
+	}
+	if tag.example != "" {
+		var example any
+		if s.Type == genai.TypeString || json.Unmarshal([]byte(tag.example), &example) != nil {
+			example = tag.example
+		}
+		s.Example = example
+	}
+	if tag.min != nil {
+		switch s.Type {
+		case genai.TypeString:
+			s.MinLength = genai.Ptr(int64(*tag.min))
+		case genai.TypeArray:
+			s.MinItems = genai.Ptr(int64(*tag.min))
+		case genai.TypeObject:
+			s.MinProperties = genai.Ptr(int64(*tag.min))
+		case genai.TypeInteger, genai.TypeNumber:
+			s.Minimum = tag.min
+		}
+	}
+	if tag.max != nil {
+		switch s.Type {
+		case genai.TypeString:
+			s.MaxLength = genai.Ptr(int64(*tag.max))
+		case genai.TypeArray:
+			s.MaxItems = genai.Ptr(int64(*tag.max))
+		case genai.TypeObject:
+			s.MaxProperties = genai.Ptr(int64(*tag.max))
+		case genai.TypeInteger, genai.TypeNumber:
+			s.Maximum = tag.max
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/darwishdev/genaistructbuilder"
+	genai "google.golang.org/genai"
+)
+
+// ValidateJSON checks raw against schema and returns a *genaistructbuilder.ValidationError
+// listing every violation, or nil when raw satisfies the schema.
+func ValidateJSON(schema *genai.Schema, raw []byte) error {
+	return validateJSON(schema, raw, false)
+}
+
+// ValidateJSONStrict is ValidateJSON that also rejects object properties the
+// schema does not declare, catching misspelled field names in hand-written JSON.
+func ValidateJSONStrict(schema *genai.Schema, raw []byte) error {
+	return validateJSON(schema, raw, true)
+}
+
+func validateJSON(schema *genai.Schema, raw []byte, strict bool) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("failed to decode JSON for validation: %w", err)
+	}
+	v := validator{strict: strict}
+	return v.check(schema, value)
+}
+
+// ValidateValue checks a value decoded with json.Decoder.UseNumber against schema.
+func ValidateValue(schema *genai.Schema, value any) error {
+	var v validator
+	return v.check(schema, value)
+}
+
+type validator struct {
+	strict     bool
+	violations []genaistructbuilder.Violation
+}
+
+func (v *validator) check(schema *genai.Schema, value any) error {
+	v.validate(schema, value, "")
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &genaistructbuilder.ValidationError{Violations: v.violations}
+}
+
+// fail records a violation of rule at path. The message must not quote value,
+// which may hold candidate data; the value is kept in Violation.Value instead.
+func (v *validator) fail(path, rule string, value any, format string, args ...any) {
+	v.violations = append(v.violations, genaistructbuilder.Violation{
+		Path:    path,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+		Value:   value,
+	})
+}
+
+func (v *validator) validate(schema *genai.Schema, value any, path string) {
+	if schema == nil {
+		return
+	}
+	if value == nil {
+		if (schema.Nullable == nil || !*schema.Nullable) && schema.Type != "" {
+			v.fail(path, "nullable", nil, "null is not allowed")
+		}
+		return
+	}
+	if len(schema.AnyOf) > 0 {
+		v.validateAnyOf(schema.AnyOf, value, path)
+	}
+	switch genai.Type(strings.ToUpper(string(schema.Type))) {
+	case genai.TypeObject:
+		object, ok := value.(map[string]any)
+		if !ok {
+			v.fail(path, "type", value, "expected object, got %s", jsonKind(value))
+			return
+		}
+		v.validateObject(schema, object, path)
+	case genai.TypeArray:
+		array, ok := value.([]any)
+		if !ok {
+			v.fail(path, "type", value, "expected array, got %s", jsonKind(value))
+			return
+		}
+		v.validateArray(schema, array, path)
+	case genai.TypeString:
+		str, ok := value.(string)
+		if !ok {
+			v.fail(path, "type", value, "expected string, got %s", jsonKind(value))
+			return
+		}
+		v.validateString(schema, str, path)
+	case genai.TypeInteger:
+		number, ok := value.(json.Number)
+		if !ok {
+			v.fail(path, "type", value, "expected integer, got %s", jsonKind(value))
+			return
+		}
+		f, err := number.Float64()
+		if err != nil || f != math.Trunc(f) {
+			v.fail(path, "type", number, "expected integer, got a fractional number")
+			return
+		}
+		v.validateNumber(schema, f, path)
+	case genai.TypeNumber:
+		number, ok := value.(json.Number)
+		if !ok {
+			v.fail(path, "type", value, "expected number, got %s", jsonKind(value))
+			return
+		}
+		f, err := number.Float64()
+		if err != nil {
+			v.fail(path, "type", number, "invalid number")
+			return
+		}
+		v.validateNumber(schema, f, path)
+	case genai.TypeBoolean:
+		if _, ok := value.(bool); !ok {
+			v.fail(path, "type", value, "expected boolean, got %s", jsonKind(value))
+		}
+	}
+	if len(schema.Enum) > 0 {
+		if str, ok := value.(string); !ok || !slices.Contains(schema.Enum, str) {
+			v.fail(path, "enum", value, "value is not one of %v", schema.Enum)
+		}
+	}
+}
+
+func (v *validator) validateAnyOf(branches []*genai.Schema, value any, path string) {
+	for _, branch := range branches {
+		branchValidator := validator{strict: v.strict}
+		branchValidator.validate(branch, value, path)
+		if len(branchValidator.violations) == 0 {
+			return
+		}
+	}
+	v.fail(path, "anyOf", value, "value does not match any of the %d allowed schemas", len(branches))
+}
+
+func (v *validator) validateObject(schema *genai.Schema, object map[string]any, path string) {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			v.fail(pointer(path, name), "required", nil, "required property is missing")
+		}
+	}
+	if schema.MinProperties != nil && int64(len(object)) < *schema.MinProperties {
+		v.fail(path, "minProperties", nil, "expected at least %d properties, got %d", *schema.MinProperties, len(object))
+	}
+	if schema.MaxProperties != nil && int64(len(object)) > *schema.MaxProperties {
+		v.fail(path, "maxProperties", nil, "expected at most %d properties, got %d", *schema.MaxProperties, len(object))
+	}
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, declared := schema.Properties[name]
+		if !declared {
+			if v.strict && len(schema.Properties) > 0 {
+				v.fail(pointer(path, name), "additionalProperties", object[name], "property is not declared in the schema")
+			}
+			continue
+		}
+		v.validate(property, object[name], pointer(path, name))
+	}
+}
+
+func (v *validator) validateArray(schema *genai.Schema, array []any, path string) {
+	if schema.MinItems != nil && int64(len(array)) < *schema.MinItems {
+		v.fail(path, "minItems", nil, "expected at least %d items, got %d", *schema.MinItems, len(array))
+	}
+	if schema.MaxItems != nil && int64(len(array)) > *schema.MaxItems {
+		v.fail(path, "maxItems", nil, "expected at most %d items, got %d", *schema.MaxItems, len(array))
+	}
+	for i, item := range array {
+		v.validate(schema.Items, item, pointer(path, fmt.Sprint(i)))
+	}
+}
+
+var formatLayouts = map[string]string{
+	"date-time": time.RFC3339,
+	"date":      time.DateOnly,
+	"time":      time.TimeOnly,
+}
+
+func (v *validator) validateString(schema *genai.Schema, str string, path string) {
+	length := int64(len([]rune(str)))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		v.fail(path, "minLength", str, "expected at least %d characters, got %d", *schema.MinLength, length)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.fail(path, "maxLength", str, "expected at most %d characters, got %d", *schema.MaxLength, length)
+	}
+	if schema.Pattern != "" {
+		if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(str) {
+			v.fail(path, "pattern", str, "value does not match pattern %q", schema.Pattern)
+		}
+	}
+	if layout, ok := formatLayouts[schema.Format]; ok {
+		if _, err := time.Parse(layout, str); err != nil {
+			v.fail(path, "format", str, "value is not a valid %s", schema.Format)
+		}
+	}
+}
+
+func (v *validator) validateNumber(schema *genai.Schema, f float64, path string) {
+	if schema.Minimum != nil && f < *schema.Minimum {
+		v.fail(path, "minimum", f, "value is below the minimum %v", *schema.Minimum)
+	}
+	if schema.Maximum != nil && f > *schema.Maximum {
+		v.fail(path, "maximum", f, "value is above the maximum %v", *schema.Maximum)
+	}
+}
+
+// pointer appends an escaped reference token to a JSON pointer.
+func pointer(path, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return path + "/" + token
+}
+
+func jsonKind(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,233 @@
+package genaistructbuilder_test
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	"github.com/darwishdev/genaistructbuilder/schema"
+	genai "google.golang.org/genai"
+	"gopkg.in/yaml.v3"
+)
+
+const jobOfferJSONSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "https://example.com/job-offer.json",
+  "type": "object",
+  "$defs": {
+    "Location": {
+      "type": "object",
+      "properties": {
+        "city": {"type": "string"},
+        "country": {"type": "string", "minLength": 2, "maxLength": 2}
+      },
+      "required": ["city"]
+    },
+    "Category": {
+      "type": "object",
+      "properties": {
+        "name": {"type": "string"},
+        "children": {"type": "array", "items": {"$ref": "#/$defs/Category"}}
+      }
+    }
+  },
+  "allOf": [
+    {"properties": {"id": {"type": "string", "format": "uuid"}}, "required": ["id"]},
+    {"properties": {"title": {"type": "string", "description": "Job title"}}, "required": ["title"]}
+  ],
+  "properties": {
+    "location": {"$ref": "#/$defs/Location", "description": "Where the job is"},
+    "remote": {"type": ["boolean", "null"]},
+    "level": {"enum": ["junior", "senior", null]},
+    "kind": {"const": "full-time"},
+    "salary": {"oneOf": [{"type": "number", "minimum": 0}, {"type": "null"}]},
+    "ref": {"anyOf": [{"type": "string"}, {"type": "integer"}]},
+    "tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
+    "extras": {"type": "object", "additionalProperties": {"type": "string"}},
+    "closed": {"type": "object", "additionalProperties": false, "properties": {"a": {"type": "string"}}},
+    "priority": {"enum": [1, 2, 3]},
+    "category": {"$ref": "#/$defs/Category"}
+  },
+  "required": ["location"]
+}`
+
+func TestFromJSONSchema(t *testing.T) {
+	s, warnings, err := schema.FromJSONSchema([]byte(jobOfferJSONSchema))
+	if err != nil {
+		t.Fatalf("❌ FromJSONSchema failed: %v", err)
+	}
+
+	if s.Type != genai.TypeObject {
+		t.Errorf("❌ root type = %q, want OBJECT", s.Type)
+	}
+	for _, name := range []string{"id", "title", "location"} {
+		if !slices.Contains(s.Required, name) {
+			t.Errorf("❌ required should contain %q after merging allOf, got %v", name, s.Required)
+		}
+	}
+	if id := s.Properties["id"]; id == nil || id.Type != genai.TypeString || id.Format != "uuid" {
+		t.Errorf("❌ allOf properties were not merged, got %+v", id)
+	}
+	location := s.Properties["location"]
+	if location.Type != genai.TypeObject || location.Description != "Where the job is" || location.Properties["country"].MaxLength == nil {
+		t.Errorf("❌ $ref with sibling description was not resolved, got %+v", location)
+	}
+	if remote := s.Properties["remote"]; remote.Type != genai.TypeBoolean || remote.Nullable == nil || !*remote.Nullable {
+		t.Errorf("❌ type arrays with null should become Nullable, got %+v", remote)
+	}
+	if level := s.Properties["level"]; !slices.Equal(level.Enum, []string{"junior", "senior"}) || level.Nullable == nil {
+		t.Errorf("❌ enum with null should become a nullable Enum, got %+v", level)
+	}
+	if kind := s.Properties["kind"]; !slices.Equal(kind.Enum, []string{"full-time"}) {
+		t.Errorf("❌ const should become a single-value Enum, got %+v", kind)
+	}
+	if salary := s.Properties["salary"]; salary.Type != genai.TypeNumber || salary.Minimum == nil || salary.Nullable == nil || len(salary.AnyOf) != 0 {
+		t.Errorf("❌ oneOf with a null branch should inline the other branch, got %+v", salary)
+	}
+	if ref := s.Properties["ref"]; len(ref.AnyOf) != 2 || ref.AnyOf[1].Type != genai.TypeInteger {
+		t.Errorf("❌ anyOf should map to AnyOf, got %+v", ref)
+	}
+
+	depth := 0
+	for category := s.Properties["category"]; category != nil && len(category.Properties) > 0; category = category.Properties["children"].Items {
+		depth++
+	}
+	if depth != genaistructbuilder.DefaultMaxRecursionDepth {
+		t.Errorf("❌ recursive $ref should be cut after %d levels, got %d", genaistructbuilder.DefaultMaxRecursionDepth, depth)
+	}
+
+	var messages []string
+	for _, w := range warnings {
+		messages = append(messages, w.String())
+	}
+	joined := strings.Join(messages, "\n")
+	for _, want := range []string{
+		"/properties/tags/uniqueItems: unsupported keyword",
+		"/properties/extras/additionalProperties: additionalProperties is not supported",
+		"/properties/priority/enum: non-string enum value 1",
+		"recursive reference \"#/$defs/Category\" truncated",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("❌ expected a warning containing %q, got:\n%s", want, joined)
+		}
+	}
+	if strings.Contains(joined, "closed") || strings.Contains(joined, "$schema") {
+		t.Errorf("❌ unexpected warnings:\n%s", joined)
+	}
+}
+
+func TestFromJSONSchema_Errors(t *testing.T) {
+	for _, doc := range []string{
+		`{"properties": {"a": {"$ref": "#/$defs/Missing"}}}`,
+		`{"properties": {"a": {"$ref": "other.json#/Thing"}}}`,
+		`{"properties": "nope"}`,
+		`not json`,
+	} {
+		if _, _, err := schema.FromJSONSchema([]byte(doc)); err == nil {
+			t.Errorf("❌ expected an error for %s", doc)
+		}
+	}
+}
+
+func TestGenAiStructBuilder_StandardJSONSchema_AllRealGenerators(t *testing.T) {
+	standardSchema := []byte(`{
+  "type": "object",
+  "$defs": {"Skill": {"type": "string"}},
+  "properties": {
+    "skills": {"type": "array", "items": {"$ref": "#/$defs/Skill"}},
+    "job_title": {"type": "string"},
+    "yearsof_experience_from": {"type": ["integer", "null"]}
+  },
+  "required": ["job_title"]
+}`)
+	tests := []generatorCase{
+		{Name: "PromptGenerator", Generator: &generator.PromptGenerator[JobSearchOutput]{Prompt: "Go developers", Schema: standardSchema}},
+		{Name: "RelationGenerator", Generator: &generator.RelationGenerator[JobSearchOutput]{RelationRecordJSON: `{}`, Schema: standardSchema}},
+		{Name: "FileRelationGenerator", Generator: &generator.FileRelationGenerator[JobSearchOutput]{RelationRecordFile: []byte("cv"), FileMIMEType: "text/plain", Schema: standardSchema}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			mock := &RecordingMock{}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, "gemini-test-mock", &output); err != nil {
+				t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+			}
+			sent := mock.Configs()[0].ResponseSchema
+			if sent.Type != genai.TypeObject || sent.Properties["skills"].Items.Type != genai.TypeString {
+				t.Errorf("❌ %s: standard JSON Schema was not converted, got %+v", tc.Name, sent)
+			}
+			if from := sent.Properties["yearsof_experience_from"]; from.Type != genai.TypeInteger || from.Nullable == nil {
+				t.Errorf("❌ %s: nullable integer was not converted, got %+v", tc.Name, from)
+			}
+		})
+	}
+}
+
+func TestToJSONSchema_RoundTrip(t *testing.T) {
+	for name, original := range map[string]*genai.Schema{
+		"tagged":   schema.Derive[TaggedCandidate](genaistructbuilder.SchemaOptions{}),
+		"complex":  schema.Derive[RichCandidate](genaistructbuilder.SchemaOptions{}),
+		"provided": schema.Derive[Offer](genaistructbuilder.SchemaOptions{}),
+		"job":      getJobSearchOutputSchema(),
+		"any_of":   {AnyOf: []*genai.Schema{{Type: genai.TypeString}, {Type: genai.TypeInteger}}, Nullable: genai.Ptr(true)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			exported, err := schema.ToJSONSchema(original)
+			if err != nil {
+				t.Fatalf("❌ ToJSONSchema failed: %v", err)
+			}
+			if strings.Contains(string(exported), `"OBJECT"`) || strings.Contains(string(exported), `"STRING"`) {
+				t.Errorf("❌ exported schema must use lowercase types:\n%s", exported)
+			}
+			if !strings.Contains(string(exported), `"$schema": "https://json-schema.org/draft/2020-12/schema"`) {
+				t.Errorf("❌ exported schema must declare draft 2020-12:\n%s", exported)
+			}
+
+			imported, warnings, err := schema.FromJSONSchema(exported)
+			if err != nil {
+				t.Fatalf("❌ FromJSONSchema failed: %v", err)
+			}
+			if len(warnings) > 0 {
+				t.Errorf("❌ round trip produced warnings: %v", warnings)
+			}
+			want, _ := json.Marshal(original)
+			got, _ := json.Marshal(imported)
+			if string(want) != string(got) {
+				t.Errorf("❌ round trip changed the schema\nwant: %s\ngot:  %s", want, got)
+			}
+		})
+	}
+}
+
+func TestToOpenAPIComponents(t *testing.T) {
+	out, err := schema.ToOpenAPIComponents(map[string]*genai.Schema{
+		"JobSearchOutput": getJobSearchOutputSchema(),
+		"Offer":           schema.Derive[Offer](genaistructbuilder.SchemaOptions{}),
+	})
+	if err != nil {
+		t.Fatalf("❌ ToOpenAPIComponents failed: %v", err)
+	}
+	var doc struct {
+		Components struct {
+			Schemas map[string]map[string]any `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("❌ output is not valid YAML: %v\n%s", err, out)
+	}
+	job := doc.Components.Schemas["JobSearchOutput"]
+	if job["type"] != "object" {
+		t.Errorf("❌ expected a lowercase object component, got %v", job["type"])
+	}
+	if _, ok := job["properties"].(map[string]any)["job_title"]; !ok {
+		t.Errorf("❌ expected job_title in the component properties:\n%s", out)
+	}
+	if bonus := doc.Components.Schemas["Offer"]["properties"].(map[string]any)["bonus"].(map[string]any); !slices.Equal(bonus["type"].([]any), []any{"string", "null"}) {
+		t.Errorf("❌ nullable fields should use a null type member, got %v", bonus["type"])
+	}
+}
@@ -0,0 +1,53 @@
+package genaistructbuilder
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/darwishdev/genaistructbuilder/internal/buildctx"
+)
+
+// Logger is the structured logger used by the builder and the generators.
+// Prompts, configs and raw responses are logged at debug level, call timings at
+// info level and failures at error level. When RedactBodies is set, prompt and
+// response bodies are replaced by a placeholder reporting only their size.
+type Logger struct {
+	*slog.Logger
+	RedactBodies bool
+}
+
+// Body returns the loggable form of a prompt or response body.
+func (l *Logger) Body(body string) string {
+	if l.RedactBodies {
+		return fmt.Sprintf("[REDACTED %d bytes]", len(body))
+	}
+	return body
+}
+
+// Err returns the loggable form of err. When RedactBodies is set, schema
+// violations are reduced to their paths and rules.
+func (l *Logger) Err(err error) any {
+	var validationErr *ValidationError
+	if !l.RedactBodies || !errors.As(err, &validationErr) {
+		return err
+	}
+	rules := make([]string, 0, len(validationErr.Violations))
+	for _, v := range validationErr.Violations {
+		rules = append(rules, fmt.Sprintf("%s (%s)", cmp.Or(v.Path, "/"), v.Rule))
+	}
+	return fmt.Sprintf("model output does not match schema: %s", strings.Join(rules, ", "))
+}
+
+var noopLogger = &Logger{Logger: slog.New(slog.DiscardHandler)}
+
+// loggerFromContext returns the logger carried by ctx, or a no-op logger.
+func loggerFromContext(ctx context.Context) *Logger {
+	if logger, ok := buildctx.Value[*Logger](ctx); ok && logger != nil {
+		return logger
+	}
+	return noopLogger
+}
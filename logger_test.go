@@ -0,0 +1,85 @@
+package genaistructbuilder_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/darwishdev/genaistructbuilder"
+)
+
+func TestGenAiStructBuilder_Logger_AllRealGenerators(t *testing.T) {
+	testModel := "gemini-test-mock"
+
+	for _, tc := range newGeneratorCases(t) {
+		t.Run(tc.Name+"/levels", func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mockGenerateContent, genaistructbuilder.WithLogger(logger))
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, testModel, &output); err != nil {
+				t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+			}
+			logs := buf.String()
+			for _, want := range []string{`"level":"DEBUG"`, `"msg":"llm request part"`, `"level":"INFO"`, `"msg":"llm call completed"`, "Mock Data Engineer"} {
+				if !strings.Contains(logs, want) {
+					t.Errorf("❌ %s: expected logs to contain %q, got:\n%s", tc.Name, want, logs)
+				}
+			}
+		})
+
+		t.Run(tc.Name+"/redacted", func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+				mockGenerateContent,
+				genaistructbuilder.WithLogger(logger),
+				genaistructbuilder.WithRedactedBodies(),
+			)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, testModel, &output); err != nil {
+				t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+			}
+			logs := buf.String()
+			for _, leaked := range []string{"Mock Data Engineer", "Extract fields."} {
+				if strings.Contains(logs, leaked) {
+					t.Errorf("❌ %s: redacted logs leaked %q:\n%s", tc.Name, leaked, logs)
+				}
+			}
+			if !strings.Contains(logs, "[REDACTED") {
+				t.Errorf("❌ %s: expected redaction placeholders, got:\n%s", tc.Name, logs)
+			}
+		})
+
+		t.Run(tc.Name+"/errors", func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelError}))
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mockGenerateContent, genaistructbuilder.WithLogger(logger))
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, "test-error-model", &output); err == nil {
+				t.Fatalf("❌ %s: expected Build to fail", tc.Name)
+			}
+			if !strings.Contains(buf.String(), `"msg":"llm call failed"`) {
+				t.Errorf("❌ %s: expected an error record, got:\n%s", tc.Name, buf.String())
+			}
+		})
+	}
+}
+
+func TestGenAiStructBuilder_Logger_NilLoggerDiscards(t *testing.T) {
+	for _, tc := range newGeneratorCases(t) {
+		builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+			mockGenerateContent,
+			genaistructbuilder.WithLogger(nil),
+			genaistructbuilder.WithRedactedBodies(),
+		)
+		var output JobSearchOutput
+		if err := builder.Build(tc.Generator, "gemini-test-mock", &output); err != nil {
+			t.Fatalf("❌ %s: Build with a nil logger failed: %v", tc.Name, err)
+		}
+	}
+}
@@ -1,13 +1,15 @@
-package genaistructbuilder
+package genaistructbuilder_test
 
 import (
 	"context"
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
-	"github.com/darwishdev/genaistructbuilder/internal"
+	"github.com/darwishdev/genaistructbuilder"
 	genai "google.golang.org/genai"
 )
 
@@ -51,8 +53,128 @@ func MockGenerateContentFunc(
 	}, nil
 }
 
-var generateContent internal.GenerateContentFunc     // Assuming testClient is defined globally elsewhere
-var mockGenerateContent internal.GenerateContentFunc // Assuming testClient is defined globally elsewhere
+// RecordingMock is the configurable GenerateContentFunc shared by the tests. It
+// answers each call with the next text of Outputs, repeating the last one, or
+// like MockGenerateContentFunc when Outputs is empty, and records the contents
+// and config of every call. Calls are counted from 1.
+type RecordingMock struct {
+	Outputs []string
+	// Delay, when set, holds call n back, returning the context error if ctx
+	// is done first.
+	Delay func(n int) time.Duration
+	// Err, when set, fails call n with the returned error unless it is nil.
+	Err func(n int) error
+	// Respond, when set, replaces the response to call n. It receives the
+	// response built from Outputs.
+	Respond func(n int, resp *genai.GenerateContentResponse) *genai.GenerateContentResponse
+
+	mu                    sync.Mutex
+	contents              [][]*genai.Content
+	configs               []*genai.GenerateContentConfig
+	inFlight, maxInFlight int
+}
+
+func (m *RecordingMock) GenerateContent(
+	ctx context.Context,
+	model string,
+	contents []*genai.Content,
+	config *genai.GenerateContentConfig,
+) (*genai.GenerateContentResponse, error) {
+	m.mu.Lock()
+	m.contents = append(m.contents, contents)
+	m.configs = append(m.configs, config)
+	n := len(m.contents)
+	m.inFlight++
+	m.maxInFlight = max(m.maxInFlight, m.inFlight)
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.inFlight--
+		m.mu.Unlock()
+	}()
+
+	if m.Delay != nil {
+		select {
+		case <-time.After(m.Delay(n)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if m.Err != nil {
+		if err := m.Err(n); err != nil {
+			return nil, err
+		}
+	}
+	var resp *genai.GenerateContentResponse
+	if len(m.Outputs) == 0 {
+		var err error
+		if resp, err = MockGenerateContentFunc(ctx, model, contents, config); err != nil {
+			return nil, err
+		}
+	} else {
+		resp = &genai.GenerateContentResponse{
+			Candidates: []*genai.Candidate{{
+				Content:      genai.NewContentFromText(m.Outputs[min(n, len(m.Outputs))-1], genai.RoleModel),
+				FinishReason: genai.FinishReasonStop,
+			}},
+		}
+	}
+	if m.Respond != nil {
+		resp = m.Respond(n, resp)
+	}
+	return resp, nil
+}
+
+// Calls returns the number of calls made so far.
+func (m *RecordingMock) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.contents)
+}
+
+// MaxInFlight returns the highest number of calls that ran at the same time.
+func (m *RecordingMock) MaxInFlight() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.maxInFlight
+}
+
+// Contents returns the contents of every call, in order.
+func (m *RecordingMock) Contents() [][]*genai.Content {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([][]*genai.Content(nil), m.contents...)
+}
+
+// Configs returns the config of every call, in order.
+func (m *RecordingMock) Configs() []*genai.GenerateContentConfig {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*genai.GenerateContentConfig(nil), m.configs...)
+}
+
+// delayed is a RecordingMock Delay holding every call back by d.
+func delayed(d time.Duration) func(int) time.Duration {
+	return func(int) time.Duration { return d }
+}
+
+// failFirst is a RecordingMock Err failing the first n calls with err.
+func failFirst(n int, err error) func(int) error {
+	return func(call int) error {
+		if call <= n {
+			return err
+		}
+		return nil
+	}
+}
+
+// respondWith is a RecordingMock Respond answering every call with resp.
+func respondWith(resp *genai.GenerateContentResponse) func(int, *genai.GenerateContentResponse) *genai.GenerateContentResponse {
+	return func(int, *genai.GenerateContentResponse) *genai.GenerateContentResponse { return resp }
+}
+
+var generateContent genaistructbuilder.GenerateContentFunc     // Assuming testClient is defined globally elsewhere
+var mockGenerateContent genaistructbuilder.GenerateContentFunc // Assuming testClient is defined globally elsewhere
 var testSchema *genai.Schema
 
 const MODEL = "gemini-2.5-flash"
@@ -85,6 +207,8 @@ func getJobSearchOutputSchema() *genai.Schema {
 	}
 }
 func TestMain(m *testing.M) {
+	generateContent = MockGenerateContentFunc
+	mockGenerateContent = MockGenerateContentFunc
 	apiKey := os.Getenv("GEMINI_API_KEY")
 	if apiKey == "" {
 		os.Exit(m.Run())
@@ -93,8 +217,6 @@ func TestMain(m *testing.M) {
 
 	// Initialize the Global Client
 	var err error
-	generateContent = MockGenerateContentFunc
-	mockGenerateContent = MockGenerateContentFunc
 
 	if err != nil {
 		panic(fmt.Sprintf("Failed to initialize GenAI client in TestMain: %v", err))
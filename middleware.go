@@ -0,0 +1,111 @@
+package genaistructbuilder
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// Middleware decorates a GenerateContentFunc with a cross-cutting concern.
+type Middleware func(next GenerateContentFunc) GenerateContentFunc
+
+// Chain composes middlewares into one. The first middleware is the outermost:
+// it sees the request first and the response last.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next GenerateContentFunc) GenerateContentFunc {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// Retry returns the retry behavior of WithRetryPolicy as a middleware, for
+// callers that want to place it explicitly in a chain.
+func Retry(policy RetryPolicy) Middleware {
+	return policy.wrap
+}
+
+// Request is a model call as seen by MutateRequest and InspectResponse.
+type Request struct {
+	Model    string
+	Contents []*genai.Content
+	Config   *genai.GenerateContentConfig
+}
+
+// MutateRequest lets mutate rewrite the model, contents or config of every
+// call. Config is a shallow copy, so replacing its fields does not leak into
+// later calls. An error aborts the call.
+func MutateRequest(mutate func(ctx context.Context, req *Request) error) Middleware {
+	return func(next GenerateContentFunc) GenerateContentFunc {
+		return func(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+			req := Request{Model: model, Contents: contents, Config: &genai.GenerateContentConfig{}}
+			if config != nil {
+				*req.Config = *config
+			}
+			if err := mutate(ctx, &req); err != nil {
+				return nil, err
+			}
+			return next(ctx, req.Model, req.Contents, req.Config)
+		}
+	}
+}
+
+// InspectResponse hands every successful response to inspect together with
+// the request that produced it. An error from inspect replaces the response.
+func InspectResponse(inspect func(ctx context.Context, req Request, resp *genai.GenerateContentResponse) error) Middleware {
+	return func(next GenerateContentFunc) GenerateContentFunc {
+		return func(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+			resp, err := next(ctx, model, contents, config)
+			if err != nil {
+				return nil, err
+			}
+			if err := inspect(ctx, Request{Model: model, Contents: contents, Config: config}, resp); err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+	}
+}
+
+// Timing reports the duration and outcome of every call to observe.
+func Timing(observe func(model string, duration time.Duration, err error)) Middleware {
+	return func(next GenerateContentFunc) GenerateContentFunc {
+		return func(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+			start := time.Now()
+			resp, err := next(ctx, model, contents, config)
+			observe(model, time.Since(start), err)
+			return resp, err
+		}
+	}
+}
+
+// Logging logs every call at info level, or at error level when it fails,
+// with its duration and token usage. A nil logger uses the builder logger.
+func Logging(logger *slog.Logger) Middleware {
+	return func(next GenerateContentFunc) GenerateContentFunc {
+		return func(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+			log := logger
+			if log == nil {
+				log = loggerFromContext(ctx).Logger
+			}
+			start := time.Now()
+			resp, err := next(ctx, model, contents, config)
+			attrs := []any{"model", model, "contents", len(contents), "duration", time.Since(start)}
+			if err != nil {
+				log.ErrorContext(ctx, "generate content failed", append(attrs, "error", err)...)
+				return resp, err
+			}
+			if resp != nil && resp.UsageMetadata != nil {
+				attrs = append(attrs,
+					"prompt_tokens", resp.UsageMetadata.PromptTokenCount,
+					"candidate_tokens", resp.UsageMetadata.CandidatesTokenCount,
+				)
+			}
+			log.InfoContext(ctx, "generate content", attrs...)
+			return resp, nil
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package genaistructbuilder_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	genai "google.golang.org/genai"
+)
+
+// tracingMiddleware appends "<name>:before" and "<name>:after" to trace.
+func tracingMiddleware(name string, trace *[]string) genaistructbuilder.Middleware {
+	return func(next genaistructbuilder.GenerateContentFunc) genaistructbuilder.GenerateContentFunc {
+		return func(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+			*trace = append(*trace, name+":before")
+			resp, err := next(ctx, model, contents, config)
+			*trace = append(*trace, name+":after")
+			return resp, err
+		}
+	}
+}
+
+func TestChain_Order(t *testing.T) {
+	var trace []string
+	fn := genaistructbuilder.Chain(tracingMiddleware("a", &trace), tracingMiddleware("b", &trace))(MockGenerateContentFunc)
+	if _, err := fn(context.Background(), "gemini-test-mock", nil, nil); err != nil {
+		t.Fatalf("❌ chained call failed: %v", err)
+	}
+	want := []string{"a:before", "b:before", "b:after", "a:after"}
+	if !slices.Equal(trace, want) {
+		t.Errorf("❌ expected %v, got %v", want, trace)
+	}
+}
+
+func TestGenAiStructBuilder_Middleware_AllRealGenerators(t *testing.T) {
+	for _, tc := range newGeneratorCases(t) {
+		t.Run(tc.Name, func(t *testing.T) {
+			var trace []string
+			mock := &RecordingMock{Err: failFirst(1, genai.APIError{Code: 503})}
+			var seenModel string
+			var seenTemperature float32
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+				mock.GenerateContent,
+				genaistructbuilder.WithRetryPolicy(testRetryPolicy()),
+				genaistructbuilder.WithMiddleware(
+					tracingMiddleware("outer", &trace),
+					genaistructbuilder.MutateRequest(func(ctx context.Context, req *genaistructbuilder.Request) error {
+						req.Model = "gemini-test-mock-override"
+						req.Config.Temperature = genai.Ptr[float32](0.5)
+						return nil
+					}),
+				),
+				genaistructbuilder.WithMiddleware(
+					genaistructbuilder.InspectResponse(func(ctx context.Context, req genaistructbuilder.Request, resp *genai.GenerateContentResponse) error {
+						seenModel = req.Model
+						seenTemperature = *req.Config.Temperature
+						return nil
+					}),
+				),
+			)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, "gemini-test-mock", &output); err != nil {
+				t.Fatalf("❌ Build failed: %v", err)
+			}
+			if !slices.Equal(trace, []string{"outer:before", "outer:after"}) {
+				t.Errorf("❌ expected middlewares to run once outside the retry loop, got %v", trace)
+			}
+			if mock.Calls() != 2 {
+				t.Errorf("❌ expected the retry to happen inside the middlewares, got %d calls", mock.Calls())
+			}
+			if seenModel != "gemini-test-mock-override" || seenTemperature != 0.5 {
+				t.Errorf("❌ expected the mutated request downstream, got model %q temperature %v", seenModel, seenTemperature)
+			}
+		})
+	}
+}
+
+func TestMutateRequest_DoesNotLeakConfig(t *testing.T) {
+	config := &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0)}
+	fn := genaistructbuilder.MutateRequest(func(ctx context.Context, req *genaistructbuilder.Request) error {
+		req.Config.Temperature = genai.Ptr[float32](1)
+		return nil
+	})(MockGenerateContentFunc)
+	if _, err := fn(context.Background(), "gemini-test-mock", nil, config); err != nil {
+		t.Fatalf("❌ call failed: %v", err)
+	}
+	if *config.Temperature != 0 {
+		t.Error("❌ MutateRequest modified the caller's config")
+	}
+}
+
+func TestInspectResponse_RejectsResponse(t *testing.T) {
+	rejected := errors.New("response rejected")
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](MockGenerateContentFunc, genaistructbuilder.WithMiddleware(
+		genaistructbuilder.InspectResponse(func(ctx context.Context, req genaistructbuilder.Request, resp *genai.GenerateContentResponse) error {
+			return rejected
+		}),
+	))
+	var output JobSearchOutput
+	if err := builder.Build(&generator.PromptGenerator[JobSearchOutput]{Prompt: "x"}, "gemini-test-mock", &output); !errors.Is(err, rejected) {
+		t.Errorf("❌ expected the inspection error, got %v", err)
+	}
+}
+
+func TestTimingAndLoggingMiddleware(t *testing.T) {
+	var observed []time.Duration
+	var buf bytes.Buffer
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput]((&RecordingMock{Delay: delayed(5 * time.Millisecond)}).GenerateContent, genaistructbuilder.WithMiddleware(
+		genaistructbuilder.Timing(func(model string, duration time.Duration, err error) {
+			observed = append(observed, duration)
+		}),
+		genaistructbuilder.Logging(slog.New(slog.NewJSONHandler(&buf, nil))),
+	))
+	var output JobSearchOutput
+	if err := builder.Build(&generator.PromptGenerator[JobSearchOutput]{Prompt: "x"}, "gemini-test-mock", &output); err != nil {
+		t.Fatalf("❌ Build failed: %v", err)
+	}
+	if len(observed) != 1 || observed[0] < 5*time.Millisecond {
+		t.Errorf("❌ expected one timed call of at least 5ms, got %v", observed)
+	}
+	if logs := buf.String(); !strings.Contains(logs, `"msg":"generate content"`) || !strings.Contains(logs, `"model":"gemini-test-mock"`) {
+		t.Errorf("❌ expected the call to be logged, got:\n%s", logs)
+	}
+}
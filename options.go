@@ -0,0 +1,90 @@
+package genaistructbuilder
+
+import "log/slog"
+
+type builderConfig struct {
+	logger     *Logger
+	retry      RetryPolicy
+	maxRepairs int
+	schema     SchemaOptions
+	budget     TokenBudget
+	generation GenerationOptions
+	middleware []Middleware
+}
+
+// BuilderOption configures a builder created by NewStructBuilder.
+type BuilderOption func(*builderConfig)
+
+func newBuilderConfig(opts []BuilderOption) builderConfig {
+	cfg := builderConfig{logger: noopLogger}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithLogger makes the builder and its generators log through logger.
+// Without it, or with a nil logger, nothing is logged.
+func WithLogger(logger *slog.Logger) BuilderOption {
+	return func(c *builderConfig) {
+		if logger == nil {
+			logger = noopLogger.Logger
+		}
+		c.logger = &Logger{Logger: logger, RedactBodies: c.logger.RedactBodies}
+	}
+}
+
+// WithRedactedBodies replaces prompt and response bodies in log records with a
+// size-only placeholder so that candidate data never reaches the logs.
+func WithRedactedBodies() BuilderOption {
+	return func(c *builderConfig) {
+		c.logger = &Logger{Logger: c.logger.Logger, RedactBodies: true}
+	}
+}
+
+// WithRetryPolicy retries transient GenerateContentFunc failures according to policy.
+func WithRetryPolicy(policy RetryPolicy) BuilderOption {
+	return func(c *builderConfig) {
+		c.retry = policy
+	}
+}
+
+// WithRepairAttempts re-prompts the model up to n times when its output fails to
+// decode or validate, sending back the invalid output and the reason it was rejected.
+func WithRepairAttempts(n int) BuilderOption {
+	return func(c *builderConfig) {
+		c.maxRepairs = n
+	}
+}
+
+// WithSchemaOptions tunes how generators derive a schema from T when none is given.
+func WithSchemaOptions(opts SchemaOptions) BuilderOption {
+	return func(c *builderConfig) {
+		c.schema = opts
+	}
+}
+
+// WithTokenBudget drops lower-priority few-shot examples so that every request
+// stays within budget.MaxInputTokens.
+func WithTokenBudget(budget TokenBudget) BuilderOption {
+	return func(c *builderConfig) {
+		c.budget = budget
+	}
+}
+
+// WithGenerationDefaults sets the generation options used for every field a
+// generator leaves unset.
+func WithGenerationDefaults(opts GenerationOptions) BuilderOption {
+	return func(c *builderConfig) {
+		c.generation = opts
+	}
+}
+
+// WithMiddleware wraps every model call in middlewares, the first one
+// outermost. Middlewares run outside the retry policy, so each of them sees a
+// call once however often it is retried. Repeated options append.
+func WithMiddleware(middlewares ...Middleware) BuilderOption {
+	return func(c *builderConfig) {
+		c.middleware = append(c.middleware, middlewares...)
+	}
+}
@@ -0,0 +1,44 @@
+package genaistructbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RepairAttempt records one model output that failed to decode or validate.
+type RepairAttempt struct {
+	Raw string
+	Err error
+}
+
+// RepairError is returned when the model output is still invalid after every
+// repair re-prompt. Attempts holds the original output followed by each repair.
+type RepairError struct {
+	Attempts []RepairAttempt
+}
+
+func (e *RepairError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "model output still invalid after %d repair attempts", len(e.Attempts)-1)
+	for i, attempt := range e.Attempts {
+		fmt.Fprintf(&b, "\n  attempt %d: %v", i+1, attempt.Err)
+	}
+	return b.String()
+}
+
+func (e *RepairError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts))
+	for _, attempt := range e.Attempts {
+		errs = append(errs, attempt.Err)
+	}
+	return errs
+}
+
+// Last returns the final failed attempt.
+func (e *RepairError) Last() RepairAttempt {
+	if len(e.Attempts) == 0 {
+		return RepairAttempt{Err: errors.New("no attempts recorded")}
+	}
+	return e.Attempts[len(e.Attempts)-1]
+}
@@ -0,0 +1,85 @@
+package genaistructbuilder_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/darwishdev/genaistructbuilder"
+	genai "google.golang.org/genai"
+)
+
+const validMockJSON = `{"skills": ["Go", "Kubernetes"], "job_title": "Mock Data Engineer", "yearsof_experience_from": 3}`
+
+func TestGenAiStructBuilder_Repair_AllRealGenerators(t *testing.T) {
+	testModel := "gemini-test-mock"
+
+	for _, tc := range newGeneratorCases(t) {
+		t.Run(tc.Name+"/repaired", func(t *testing.T) {
+			mock := &RecordingMock{Outputs: []string{`{"job_title": "Mock`, validMockJSON}}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+				mock.GenerateContent,
+				genaistructbuilder.WithRepairAttempts(2),
+			)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, testModel, &output); err != nil {
+				t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+			}
+			CheckOutput(t, output, tc.Name)
+			if mock.Calls() != 2 {
+				t.Fatalf("❌ %s: expected 2 calls, got %d", tc.Name, mock.Calls())
+			}
+			first, second := mock.Contents()[0], mock.Contents()[1]
+			if len(second) != len(first)+2 {
+				t.Fatalf("❌ %s: expected the repair call to add 2 turns, got %d -> %d", tc.Name, len(first), len(second))
+			}
+			modelTurn, repairTurn := second[len(second)-2], second[len(second)-1]
+			if modelTurn.Role != genai.RoleModel || modelTurn.Parts[0].Text != `{"job_title": "Mock` {
+				t.Errorf("❌ %s: expected the invalid output echoed as a model turn, got %+v", tc.Name, modelTurn)
+			}
+			if repairTurn.Role != genai.RoleUser || !strings.Contains(repairTurn.Parts[0].Text, "unexpected end of JSON input") {
+				t.Errorf("❌ %s: expected the decode error in the repair turn, got %q", tc.Name, repairTurn.Parts[0].Text)
+			}
+		})
+
+		t.Run(tc.Name+"/exhausted", func(t *testing.T) {
+			mock := &RecordingMock{Outputs: []string{`not json`, `{"skills": "Go"}`}}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+				mock.GenerateContent,
+				genaistructbuilder.WithRepairAttempts(2),
+			)
+
+			output := JobSearchOutput{JobTitle: "untouched"}
+			err := builder.Build(tc.Generator, testModel, &output)
+			var repairErr *genaistructbuilder.RepairError
+			if !errors.As(err, &repairErr) {
+				t.Fatalf("❌ %s: expected a RepairError, got %v", tc.Name, err)
+			}
+			if len(repairErr.Attempts) != 3 || mock.Calls() != 3 {
+				t.Fatalf("❌ %s: expected 3 recorded attempts and calls, got %d and %d", tc.Name, len(repairErr.Attempts), mock.Calls())
+			}
+			if repairErr.Attempts[0].Raw != "not json" || repairErr.Last().Raw != `{"skills": "Go"}` {
+				t.Errorf("❌ %s: unexpected recorded attempts: %+v", tc.Name, repairErr.Attempts)
+			}
+			if output.JobTitle != "untouched" {
+				t.Errorf("❌ %s: output must not be modified by invalid attempts, got %+v", tc.Name, output)
+			}
+		})
+
+		t.Run(tc.Name+"/disabled", func(t *testing.T) {
+			mock := &RecordingMock{Outputs: []string{`not json`, validMockJSON}}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+				mock.GenerateContent,
+			)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, testModel, &output); err == nil {
+				t.Fatalf("❌ %s: expected Build to fail without repairs", tc.Name)
+			}
+			if mock.Calls() != 1 {
+				t.Errorf("❌ %s: expected a single call, got %d", tc.Name, mock.Calls())
+			}
+		})
+	}
+}
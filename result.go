@@ -0,0 +1,51 @@
+package genaistructbuilder
+
+import (
+	"context"
+	"time"
+
+	"github.com/darwishdev/genaistructbuilder/internal/buildctx"
+	"google.golang.org/genai"
+)
+
+// Usage is the token usage reported by the model, summed over every call made
+// for one build, including retries and repair re-prompts.
+type Usage struct {
+	PromptTokens    int
+	CandidateTokens int
+	CachedTokens    int
+	ThoughtTokens   int
+	TotalTokens     int
+}
+
+// Result is the outcome of BuildWithResult. Raw, ModelVersion, FinishReason and
+// RequestID describe the last model response.
+type Result[T any] struct {
+	Value        T
+	Raw          string
+	Usage        Usage
+	ModelVersion string
+	FinishReason genai.FinishReason
+	// Attempts counts GenerateContentFunc calls, including retries and repairs.
+	Attempts int
+	// CacheHits counts the attempts answered by a ResponseCache. Their usage is
+	// the usage recorded with the cached response, not tokens billed again.
+	CacheHits int
+	Duration  time.Duration
+	RequestID string
+	// Err is the error the build ended with, so BuildBatch can report it per item.
+	Err error
+}
+
+// collectResponses records every call made through next in the context collector.
+func collectResponses(next GenerateContentFunc) GenerateContentFunc {
+	return func(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+		collector := buildctx.CollectorFrom(ctx)
+		collector.RecordAttempt()
+		resp, err := next(ctx, model, contents, config)
+		if err == nil {
+			collector.RecordResponse(resp)
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,78 @@
+package genaistructbuilder_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	genai "google.golang.org/genai"
+)
+
+// withUsage makes every response report fixed token usage and a numbered response ID.
+func withUsage(n int, resp *genai.GenerateContentResponse) *genai.GenerateContentResponse {
+	resp.ModelVersion = "gemini-test-mock-001"
+	resp.ResponseID = fmt.Sprintf("response-%d", n)
+	resp.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        100,
+		CandidatesTokenCount:    20,
+		CachedContentTokenCount: 40,
+		ThoughtsTokenCount:      5,
+		TotalTokenCount:         125,
+	}
+	return resp
+}
+
+func TestGenAiStructBuilder_BuildWithResult_AllRealGenerators(t *testing.T) {
+	for _, tc := range newGeneratorCases(t) {
+		t.Run(tc.Name, func(t *testing.T) {
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+				(&RecordingMock{Outputs: []string{"not json", validMockJSON}, Respond: withUsage}).GenerateContent,
+				genaistructbuilder.WithRepairAttempts(1),
+			)
+			result, err := builder.BuildWithResult(context.Background(), tc.Generator, "gemini-test-mock")
+			if err != nil {
+				t.Fatalf("❌ BuildWithResult failed: %v", err)
+			}
+			if result.Value.JobTitle != "Mock Data Engineer" || result.Raw != validMockJSON {
+				t.Errorf("❌ unexpected value or raw output: %+v / %q", result.Value, result.Raw)
+			}
+			want := genaistructbuilder.Usage{PromptTokens: 200, CandidateTokens: 40, CachedTokens: 80, ThoughtTokens: 10, TotalTokens: 250}
+			if result.Usage != want {
+				t.Errorf("❌ expected usage summed over both calls %+v, got %+v", want, result.Usage)
+			}
+			if result.Attempts != 2 || result.RequestID != "response-2" || result.ModelVersion != "gemini-test-mock-001" ||
+				result.FinishReason != genai.FinishReasonStop || result.Duration <= 0 {
+				t.Errorf("❌ unexpected result metadata: %+v", result)
+			}
+		})
+	}
+}
+
+func TestGenAiStructBuilder_BuildWithResult_CountsRetries(t *testing.T) {
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+		(&RecordingMock{Err: failFirst(2, genai.APIError{Code: 503, Status: "UNAVAILABLE"})}).GenerateContent,
+		genaistructbuilder.WithRetryPolicy(testRetryPolicy()),
+	)
+	result, err := builder.BuildWithResult(context.Background(), &generator.PromptGenerator[JobSearchOutput]{Prompt: "x"}, "gemini-test-mock")
+	if err != nil {
+		t.Fatalf("❌ BuildWithResult failed: %v", err)
+	}
+	if result.Attempts != 3 {
+		t.Errorf("❌ expected 3 attempts including retries, got %d", result.Attempts)
+	}
+}
+
+func TestGenAiStructBuilder_BuildWithResult_PartialOnError(t *testing.T) {
+	builder := genaistructbuilder.NewStructBuilder[JobSearchOutput]((&RecordingMock{Outputs: []string{"not json"}, Respond: withUsage}).GenerateContent)
+	result, err := builder.BuildWithResult(context.Background(), &generator.PromptGenerator[JobSearchOutput]{Prompt: "x"}, "gemini-test-mock")
+	var decodeErr *genaistructbuilder.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("❌ expected a DecodeError, got %v", err)
+	}
+	if result.Raw != "not json" || result.Usage.TotalTokens != 125 || result.Attempts != 1 {
+		t.Errorf("❌ expected the failed call to be recorded, got %+v", result)
+	}
+}
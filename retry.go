@@ -0,0 +1,149 @@
+package genaistructbuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// RetryPolicy controls how transient GenerateContentFunc failures are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of calls including the first one. Values
+	// below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts. Zero means no cap.
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff after each attempt. Zero defaults to 2.
+	Multiplier float64
+	// Jitter randomly shortens each backoff by up to this fraction (0 to 1).
+	Jitter float64
+	// PerAttemptTimeout bounds every single call. Zero means only the caller's
+	// context applies.
+	PerAttemptTimeout time.Duration
+	// Retryable reports whether an error is worth retrying. Nil uses IsRetryable.
+	Retryable func(err error) bool
+	// OnRetry is called before waiting for each retry.
+	OnRetry func(event RetryEvent)
+}
+
+// RetryEvent describes a failed attempt that is about to be retried.
+type RetryEvent struct {
+	Model   string
+	Attempt int
+	Err     error
+	Backoff time.Duration
+}
+
+// DefaultRetryPolicy retries up to 4 attempts with jittered exponential backoff
+// starting at 500ms and capped at 10s.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// IsRetryable reports whether err is a transient provider failure: a
+// genai.APIError with a 408, 429 or 5xx code, or a per-attempt deadline.
+func IsRetryable(err error) bool {
+	if code, ok := apiErrorCode(err); ok {
+		switch code {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests,
+			http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+func apiErrorCode(err error) (int, bool) {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code, true
+	}
+	var apiErrPtr *genai.APIError
+	if errors.As(err, &apiErrPtr) && apiErrPtr != nil {
+		return apiErrPtr.Code, true
+	}
+	return 0, false
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= multiplier
+		if p.MaxBackoff > 0 && backoff >= float64(p.MaxBackoff) {
+			backoff = float64(p.MaxBackoff)
+			break
+		}
+	}
+	if p.Jitter > 0 {
+		backoff -= backoff * p.Jitter * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
+
+func (p RetryPolicy) call(ctx context.Context, next GenerateContentFunc, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	if p.PerAttemptTimeout <= 0 {
+		return next(ctx, model, contents, config)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, p.PerAttemptTimeout)
+	defer cancel()
+	return next(attemptCtx, model, contents, config)
+}
+
+// wrap returns a GenerateContentFunc that retries next according to the policy.
+func (p RetryPolicy) wrap(next GenerateContentFunc) GenerateContentFunc {
+	if p.MaxAttempts < 2 {
+		return next
+	}
+	return func(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+		for attempt := 1; ; attempt++ {
+			resp, err := p.call(ctx, next, model, contents, config)
+			if err == nil {
+				return resp, nil
+			}
+			if ctx.Err() != nil || !p.retryable(err) {
+				return nil, err
+			}
+			if attempt >= p.MaxAttempts {
+				return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+			}
+			backoff := p.backoff(attempt)
+			loggerFromContext(ctx).WarnContext(ctx, "retrying llm call", "model", model, "attempt", attempt, "backoff", backoff, "error", err)
+			if p.OnRetry != nil {
+				p.OnRetry(RetryEvent{Model: model, Attempt: attempt, Err: err, Backoff: backoff})
+			}
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, fmt.Errorf("retry interrupted after %d attempts: %w", attempt, ctx.Err())
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package genaistructbuilder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/darwishdev/genaistructbuilder"
+	genai "google.golang.org/genai"
+)
+
+func testRetryPolicy() genaistructbuilder.RetryPolicy {
+	return genaistructbuilder.RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		Jitter:         0.5,
+	}
+}
+
+func TestGenAiStructBuilder_Retry_AllRealGenerators(t *testing.T) {
+	testModel := "gemini-test-mock"
+	unavailable := genai.APIError{Code: 503, Status: "UNAVAILABLE"}
+
+	for _, tc := range newGeneratorCases(t) {
+		t.Run(tc.Name+"/recovers", func(t *testing.T) {
+			mock := &RecordingMock{Err: failFirst(3, unavailable)}
+			var events []genaistructbuilder.RetryEvent
+			policy := testRetryPolicy()
+			policy.OnRetry = func(event genaistructbuilder.RetryEvent) { events = append(events, event) }
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+				mock.GenerateContent,
+				genaistructbuilder.WithRetryPolicy(policy),
+			)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, testModel, &output); err != nil {
+				t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+			}
+			CheckOutput(t, output, tc.Name)
+			if got := mock.Calls(); got != 4 {
+				t.Errorf("❌ %s: expected 4 calls, got %d", tc.Name, got)
+			}
+			if len(events) != 3 {
+				t.Fatalf("❌ %s: expected 3 retry events, got %d", tc.Name, len(events))
+			}
+			for i, event := range events {
+				var apiErr genai.APIError
+				if event.Attempt != i+1 || !errors.As(event.Err, &apiErr) || apiErr.Code != unavailable.Code || event.Backoff > 5*time.Millisecond {
+					t.Errorf("❌ %s: unexpected retry event %d: %+v", tc.Name, i, event)
+				}
+			}
+		})
+
+		t.Run(tc.Name+"/exhausted", func(t *testing.T) {
+			mock := &RecordingMock{Err: failFirst(10, genai.APIError{Code: 429})}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+				mock.GenerateContent,
+				genaistructbuilder.WithRetryPolicy(testRetryPolicy()),
+			)
+
+			var output JobSearchOutput
+			err := builder.Build(tc.Generator, testModel, &output)
+			var apiErr genai.APIError
+			if !errors.As(err, &apiErr) || apiErr.Code != 429 {
+				t.Fatalf("❌ %s: expected the last APIError to be kept, got %v", tc.Name, err)
+			}
+			if got := mock.Calls(); got != 4 {
+				t.Errorf("❌ %s: expected 4 calls, got %d", tc.Name, got)
+			}
+		})
+
+		t.Run(tc.Name+"/not_retryable", func(t *testing.T) {
+			mock := &RecordingMock{Err: failFirst(1, genai.APIError{Code: 400})}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+				mock.GenerateContent,
+				genaistructbuilder.WithRetryPolicy(testRetryPolicy()),
+			)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, testModel, &output); err == nil {
+				t.Fatalf("❌ %s: expected Build to fail", tc.Name)
+			}
+			if got := mock.Calls(); got != 1 {
+				t.Errorf("❌ %s: expected a single call, got %d", tc.Name, got)
+			}
+		})
+
+		t.Run(tc.Name+"/per_attempt_timeout", func(t *testing.T) {
+			mock := &RecordingMock{Delay: func(n int) time.Duration {
+				if n == 1 {
+					return time.Second
+				}
+				return 0
+			}}
+			policy := testRetryPolicy()
+			policy.PerAttemptTimeout = 20 * time.Millisecond
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+				mock.GenerateContent,
+				genaistructbuilder.WithRetryPolicy(policy),
+			)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, testModel, &output); err != nil {
+				t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+			}
+			if got := mock.Calls(); got != 2 {
+				t.Errorf("❌ %s: expected 2 calls, got %d", tc.Name, got)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		Name string
+		Err  error
+		Want bool
+	}{
+		{"429", genai.APIError{Code: 429}, true},
+		{"503_pointer", &genai.APIError{Code: 503}, true},
+		{"400", genai.APIError{Code: 400}, false},
+		{"deadline", context.DeadlineExceeded, true},
+		{"canceled", context.Canceled, false},
+		{"plain", errors.New("boom"), false},
+	}
+	for _, tc := range tests {
+		if got := genaistructbuilder.IsRetryable(tc.Err); got != tc.Want {
+			t.Errorf("❌ IsRetryable(%s) = %v, want %v", tc.Name, got, tc.Want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Package schema converts between genai.Schema and the standard JSON Schema
+// documents used by API contracts, and exposes the reflection used by the
+// generators to derive a schema from a Go type.
+package schema
+
+import (
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/internal"
+	genai "google.golang.org/genai"
+)
+
+// Warning reports a JSON Schema keyword that could not be represented exactly.
+type Warning = internal.SchemaWarning
+
+// FromJSONSchema converts a JSON Schema (draft 2020-12 and earlier, or an
+// OpenAPI schema object) into a genai.Schema. Local $ref/$defs are resolved,
+// allOf is merged, oneOf/anyOf map to AnyOf, const/enum map to Enum and null
+// types map to Nullable. Keywords genai.Schema cannot express are returned as
+// warnings rather than failing the conversion.
+func FromJSONSchema(data []byte) (*genai.Schema, []Warning, error) {
+	return internal.ConvertJSONSchema(data)
+}
+
+// Derive returns the schema the generators derive for T when no explicit
+// schema bytes are given.
+func Derive[T any](opts genaistructbuilder.SchemaOptions) *genai.Schema {
+	return internal.BuildSchemaWithOptions(new(T), opts)
+}
+
+// ToJSONSchema renders s, including derived schemas, as a draft 2020-12 JSON
+// Schema document with lowercase types. FromJSONSchema reads it back unchanged.
+func ToJSONSchema(s *genai.Schema) ([]byte, error) {
+	return internal.ExportJSONSchema(s)
+}
+
+// ToOpenAPIComponents renders schemas as an OpenAPI 3.1 components document in
+// YAML, keyed by component name.
+func ToOpenAPIComponents(schemas map[string]*genai.Schema) ([]byte, error) {
+	return internal.ExportOpenAPIComponents(schemas)
+}
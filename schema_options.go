@@ -0,0 +1,11 @@
+package genaistructbuilder
+
+// DefaultMaxRecursionDepth is how many times a self-referential type may nest
+// inside itself in a derived schema before the recursion is cut off.
+const DefaultMaxRecursionDepth = 3
+
+// SchemaOptions tunes how response schemas are derived from Go types.
+type SchemaOptions struct {
+	// MaxRecursionDepth bounds self-referential types. Zero uses DefaultMaxRecursionDepth.
+	MaxRecursionDepth int
+}
@@ -0,0 +1,38 @@
+package genaistructbuilder
+
+import (
+	"reflect"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// SchemaProvider is implemented by types that describe their own response
+// schema, such as money amounts or ISO country codes. Schema derivation uses
+// the returned schema instead of reflecting over the type.
+type SchemaProvider interface {
+	GenAISchema() *genai.Schema
+}
+
+var schemaRegistry sync.Map // reflect.Type -> *genai.Schema
+
+// RegisterSchema makes schema derivation use schema for T. It is meant for
+// third-party types that cannot implement SchemaProvider and takes precedence
+// over it.
+func RegisterSchema[T any](schema *genai.Schema) {
+	RegisterSchemaForType(reflect.TypeFor[T](), schema)
+}
+
+// RegisterSchemaForType is RegisterSchema for a reflect.Type.
+func RegisterSchemaForType(t reflect.Type, schema *genai.Schema) {
+	schemaRegistry.Store(t, schema)
+}
+
+// RegisteredSchema returns the schema registered for t, if any.
+func RegisteredSchema(t reflect.Type) (*genai.Schema, bool) {
+	schema, ok := schemaRegistry.Load(t)
+	if !ok {
+		return nil, false
+	}
+	return schema.(*genai.Schema), true
+}
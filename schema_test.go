@@ -0,0 +1,384 @@
+package genaistructbuilder_test
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	"github.com/darwishdev/genaistructbuilder/internal"
+	"github.com/darwishdev/genaistructbuilder/schema"
+	genai "google.golang.org/genai"
+)
+
+func propertyNames(s *genai.Schema) []string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestResolveSchema(t *testing.T) {
+	derived, err := internal.ResolveSchema[JobSearchOutput](context.Background(), nil)
+	if err != nil {
+		t.Fatalf("❌ ResolveSchema failed: %v", err)
+	}
+	want := []string{"company", "industry", "job_title", "location", "skills", "yearsof_experience_from", "yearsof_experience_to"}
+	if got := propertyNames(derived); !slices.Equal(got, want) {
+		t.Errorf("❌ derived property names = %v, want %v", got, want)
+	}
+	if derived.Properties["skills"].Type != genai.TypeArray || derived.Properties["skills"].Items.Type != genai.TypeString {
+		t.Errorf("❌ skills should be an array of strings, got %+v", derived.Properties["skills"])
+	}
+	if derived.Properties["yearsof_experience_to"].Type != genai.TypeInteger {
+		t.Errorf("❌ yearsof_experience_to should be an integer, got %+v", derived.Properties["yearsof_experience_to"])
+	}
+
+	explicit, err := internal.ResolveSchema[JobSearchOutput](context.Background(), []byte(`{"type": "OBJECT", "properties": {"only": {"type": "STRING"}}}`))
+	if err != nil {
+		t.Fatalf("❌ ResolveSchema failed: %v", err)
+	}
+	if got := propertyNames(explicit); !slices.Equal(got, []string{"only"}) {
+		t.Errorf("❌ explicit schema bytes must take precedence, got %v", got)
+	}
+}
+
+func TestGenAiStructBuilder_DerivedSchema_AllRealGenerators(t *testing.T) {
+	testModel := "gemini-test-mock"
+	tests := []generatorCase{
+		{Name: "PromptGenerator", Generator: &generator.PromptGenerator[JobSearchOutput]{Prompt: "Go developers", Instructions: "Extract fields."}},
+		{Name: "RelationGenerator", Generator: &generator.RelationGenerator[JobSearchOutput]{RelationEntity: "Job Entity", RelationRecordJSON: `{}`, Instructions: "Extract fields."}},
+		{Name: "FileRelationGenerator", Generator: &generator.FileRelationGenerator[JobSearchOutput]{RelationEntity: "Resume File", RelationRecordFile: []byte("resume"), FileMIMEType: "text/plain", Instructions: "Extract fields."}},
+	}
+	want := []string{"company", "industry", "job_title", "location", "skills", "yearsof_experience_from", "yearsof_experience_to"}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			mock := &RecordingMock{}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, testModel, &output); err != nil {
+				t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+			}
+			CheckOutput(t, output, tc.Name)
+			configs := mock.Configs()
+			if len(configs) != 1 || configs[0].ResponseSchema == nil {
+				t.Fatalf("❌ %s: expected a response schema to be sent", tc.Name)
+			}
+			if got := propertyNames(configs[0].ResponseSchema); !slices.Equal(got, want) {
+				t.Errorf("❌ %s: sent property names = %v, want %v", tc.Name, got, want)
+			}
+		})
+	}
+}
+
+type TaggedCandidate struct {
+	Name      string   `json:"name" genai:"description=Full name, as written on the CV,example=Alex Doe"`
+	Level     string   `json:"level" genai:"enum=junior|mid|senior,description=Seniority level"`
+	Years     int      `json:"years" genai:"min=0,max=50,example=7"`
+	Skills    []string `json:"skills" genai:"min=1,max=5"`
+	Available string   `json:"available_from,omitempty" genai:"format=date-time"`
+	Summary   *string  `json:"summary" genai:"nullable,optional,max=280"`
+	Email     string   `json:"email,omitempty" genai:"required,pattern=^[^@]+@[^@]+$"`
+	Position  string   `json:"position" description:"The official job title."`
+	Internal  string   `json:"-"`
+}
+
+func TestBuildSchema_TagVocabulary(t *testing.T) {
+	s := internal.BuildSchema(TaggedCandidate{})
+
+	wantOrder := []string{"name", "level", "years", "skills", "available_from", "summary", "email", "position"}
+	if !slices.Equal(s.PropertyOrdering, wantOrder) {
+		t.Errorf("❌ PropertyOrdering = %v, want %v", s.PropertyOrdering, wantOrder)
+	}
+	if wantRequired := []string{"name", "level", "years", "skills", "email", "position"}; !slices.Equal(s.Required, wantRequired) {
+		t.Errorf("❌ Required = %v, want %v", s.Required, wantRequired)
+	}
+	if _, ok := s.Properties["Internal"]; ok {
+		t.Errorf("❌ fields tagged json:\"-\" must be skipped")
+	}
+
+	name := s.Properties["name"]
+	if name.Description != "Full name, as written on the CV" || name.Example != "Alex Doe" {
+		t.Errorf("❌ name: unexpected description/example %q / %v", name.Description, name.Example)
+	}
+	level := s.Properties["level"]
+	if !slices.Equal(level.Enum, []string{"junior", "mid", "senior"}) || level.Format != "enum" || level.Description != "Seniority level" {
+		t.Errorf("❌ level: unexpected enum schema %+v", level)
+	}
+	years := s.Properties["years"]
+	if years.Minimum == nil || *years.Minimum != 0 || years.Maximum == nil || *years.Maximum != 50 || years.Example != float64(7) {
+		t.Errorf("❌ years: unexpected range/example %+v", years)
+	}
+	skills := s.Properties["skills"]
+	if skills.MinItems == nil || *skills.MinItems != 1 || skills.MaxItems == nil || *skills.MaxItems != 5 {
+		t.Errorf("❌ skills: unexpected item bounds %+v", skills)
+	}
+	if s.Properties["available_from"].Format != "date-time" {
+		t.Errorf("❌ available_from: expected date-time format, got %q", s.Properties["available_from"].Format)
+	}
+	summary := s.Properties["summary"]
+	if summary.Nullable == nil || !*summary.Nullable || summary.MaxLength == nil || *summary.MaxLength != 280 {
+		t.Errorf("❌ summary: unexpected nullable/length %+v", summary)
+	}
+	if s.Properties["email"].Pattern != "^[^@]+@[^@]+$" {
+		t.Errorf("❌ email: unexpected pattern %q", s.Properties["email"].Pattern)
+	}
+	if s.Properties["position"].Description != "The official job title." {
+		t.Errorf("❌ position: legacy description tag ignored")
+	}
+}
+
+type Audit struct {
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by"`
+	Name      string    `json:"name"`
+}
+
+type Category struct {
+	Name     string     `json:"name"`
+	Children []Category `json:"children"`
+}
+
+type RichCandidate struct {
+	Audit
+	*Category `json:"category"`
+	Name      string            `json:"name"`
+	Scores    map[string]int    `json:"scores"`
+	Meta      any               `json:"meta"`
+	Raw       json.RawMessage   `json:"raw"`
+	Attempts  uint8             `json:"attempts"`
+	Photo     []byte            `json:"photo"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+func TestBuildSchema_ComplexTypes(t *testing.T) {
+	s := internal.BuildSchema(&RichCandidate{})
+
+	wantOrder := []string{"created_at", "created_by", "name", "category", "scores", "meta", "raw", "attempts", "photo", "labels"}
+	if !slices.Equal(s.PropertyOrdering, wantOrder) {
+		t.Errorf("❌ PropertyOrdering = %v, want %v", s.PropertyOrdering, wantOrder)
+	}
+	if created := s.Properties["created_at"]; created.Type != genai.TypeString || created.Format != "date-time" {
+		t.Errorf("❌ time.Time should be a date-time string, got %+v", created)
+	}
+	scores := s.Properties["scores"]
+	if scores.Type != genai.TypeArray || scores.Items.Properties["key"].Type != genai.TypeString || scores.Items.Properties["value"].Type != genai.TypeInteger {
+		t.Errorf("❌ maps should be key/value arrays, got %+v", scores)
+	}
+	if meta, raw := s.Properties["meta"], s.Properties["raw"]; meta.Type != genai.TypeString || meta.Nullable == nil || raw.Type != genai.TypeString {
+		t.Errorf("❌ any and json.RawMessage should be nullable strings, got %+v / %+v", meta, raw)
+	}
+	if slices.Contains(s.Required, "meta") || slices.Contains(s.Required, "raw") {
+		t.Errorf("❌ any and json.RawMessage must not be required, got %v", s.Required)
+	}
+	if attempts := s.Properties["attempts"]; attempts.Type != genai.TypeInteger || attempts.Minimum == nil || *attempts.Minimum != 0 {
+		t.Errorf("❌ uint kinds should be non-negative integers, got %+v", attempts)
+	}
+	if photo := s.Properties["photo"]; photo.Type != genai.TypeString || photo.Format != "byte" {
+		t.Errorf("❌ []byte should be a base64 string, got %+v", photo)
+	}
+	if s.Properties["name"].Description != "" || s.Properties["created_by"] == nil {
+		t.Errorf("❌ the outer name must win over the promoted Audit.Name")
+	}
+
+	category := s.Properties["category"]
+	depth := 0
+	for len(category.Properties) > 0 {
+		depth++
+		category = category.Properties["children"].Items
+	}
+	if depth != genaistructbuilder.DefaultMaxRecursionDepth {
+		t.Errorf("❌ expected recursion to stop after %d levels, got %d", genaistructbuilder.DefaultMaxRecursionDepth, depth)
+	}
+	assertRecursionLeaf(t, "category past the limit", category)
+
+	shallow := internal.BuildSchemaWithOptions(Category{}, genaistructbuilder.SchemaOptions{MaxRecursionDepth: 1})
+	if children := shallow.Properties["children"]; children == nil || !slices.Contains(shallow.Required, "children") {
+		t.Errorf("❌ MaxRecursionDepth 1 must keep the children field, got %+v", shallow)
+	} else {
+		assertRecursionLeaf(t, "first nested Category", children.Items)
+	}
+	assertNoEmptyObjects(t, "", s)
+	assertNoEmptyObjects(t, "", shallow)
+}
+
+// assertNoEmptyObjects fails for every OBJECT schema without properties, other
+// than the nullable leaf that cuts off recursion.
+func assertNoEmptyObjects(t *testing.T, path string, s *genai.Schema) {
+	t.Helper()
+	if s == nil {
+		return
+	}
+	if s.Type == genai.TypeObject && len(s.Properties) == 0 && s.Nullable == nil {
+		t.Errorf("❌ %s: OBJECT schema without properties", cmp.Or(path, "/"))
+	}
+	for name, property := range s.Properties {
+		assertNoEmptyObjects(t, path+"/"+name, property)
+	}
+	assertNoEmptyObjects(t, path+"/items", s.Items)
+}
+
+func assertRecursionLeaf(t *testing.T, name string, s *genai.Schema) {
+	t.Helper()
+	if s == nil || s.Type != genai.TypeObject || s.Nullable == nil || !*s.Nullable || len(s.Properties) != 0 {
+		t.Errorf("❌ %s: expected a nullable OBJECT leaf, got %+v", name, s)
+	}
+}
+
+type ListNode struct {
+	Next *ListNode `json:"next"`
+}
+
+type ListHead struct {
+	ID   string   `json:"id"`
+	Node ListNode `json:"node"`
+}
+
+func TestBuildSchema_RecursionLimitKeepsFields(t *testing.T) {
+	node := schema.Derive[ListNode](genaistructbuilder.SchemaOptions{MaxRecursionDepth: 2})
+	if node.Type != genai.TypeObject || !slices.Equal(node.Required, []string{"next"}) {
+		t.Fatalf("❌ a struct whose only field is its recursive link must stay an object, got %+v", node)
+	}
+	if next := node.Properties["next"]; next.Type != genai.TypeObject || len(next.Properties) != 1 {
+		t.Errorf("❌ expected one nested level before the limit, got %+v", next)
+	} else {
+		assertRecursionLeaf(t, "next past the limit", next.Properties["next"])
+	}
+
+	head := schema.Derive[ListHead](genaistructbuilder.SchemaOptions{MaxRecursionDepth: 1})
+	if !slices.Equal(head.PropertyOrdering, []string{"id", "node"}) || !slices.Equal(head.Required, []string{"id", "node"}) {
+		t.Errorf("❌ expected the wrapper to keep its required node, got %v / %v", head.PropertyOrdering, head.Required)
+	}
+	assertRecursionLeaf(t, "node.next", head.Properties["node"].Properties["next"])
+
+	var output ListHead
+	builder := genaistructbuilder.NewStructBuilder[ListHead](
+		(&RecordingMock{Outputs: []string{`{"id": "a", "node": {"next": null}}`}}).GenerateContent,
+		genaistructbuilder.WithSchemaOptions(genaistructbuilder.SchemaOptions{MaxRecursionDepth: 1}),
+	)
+	if err := builder.Build(&generator.PromptGenerator[ListHead]{Prompt: "x", ValidateOutput: true}, "gemini-test-mock", &output); err != nil || output.ID != "a" {
+		t.Errorf("❌ expected a null leaf to validate and decode, got %+v, %v", output, err)
+	}
+}
+
+type CategoryAttributes struct {
+	Attrs    map[string]string               `json:"attrs"`
+	Nested   map[string]map[string]int       `json:"nested"`
+	Children []CategoryAttributes            `json:"children,omitempty"`
+	Tags     map[string][]CategoryAttributes `json:"tags,omitempty"`
+}
+
+func TestGenAiStructBuilder_DerivedMapSchema_RoundTrip(t *testing.T) {
+	s := internal.BuildSchema(CategoryAttributes{})
+	assertNoEmptyObjects(t, "", s)
+
+	raw := `{"attrs": [{"key": "color", "value": "red"}], "nested": [{"key": "a", "value": [{"key": "b", "value": 2}]}], "children": [{"attrs": [], "nested": []}]}`
+	mock := &RecordingMock{Outputs: []string{raw}}
+	builder := genaistructbuilder.NewStructBuilder[CategoryAttributes](mock.GenerateContent)
+	g := &generator.PromptGenerator[CategoryAttributes]{
+		Prompt:         "Describe the category",
+		ValidateOutput: true,
+		ExampleFormat:  genaistructbuilder.ExampleFormatTurns,
+		Examples: []genaistructbuilder.PromptExample[CategoryAttributes]{
+			{Prompt: "shoes", Response: CategoryAttributes{Attrs: map[string]string{"size": "42", "brand": "x"}, Nested: map[string]map[string]int{}}},
+		},
+	}
+
+	var output CategoryAttributes
+	if err := builder.Build(g, "gemini-test-mock", &output); err != nil {
+		t.Fatalf("❌ Build failed: %v", err)
+	}
+	if output.Attrs["color"] != "red" || output.Nested["a"]["b"] != 2 || len(output.Children) != 1 {
+		t.Errorf("❌ expected key/value arrays to decode into maps, got %+v", output)
+	}
+	example := contentsText(mock.Contents()[0][1:2])
+	if !strings.Contains(example, `"key": "brand"`) || strings.Index(example, "brand") > strings.Index(example, "size") {
+		t.Errorf("❌ expected example maps as sorted key/value arrays, got %s", example)
+	}
+	if err := internal.ValidateJSON(s, []byte(example)); err != nil {
+		t.Errorf("❌ expected the rendered example to satisfy the schema: %v", err)
+	}
+}
+
+type conflictA struct {
+	ID string
+}
+type conflictB struct {
+	ID string
+}
+type ConflictingEmbeds struct {
+	conflictA
+	conflictB
+	Title string `json:"title"`
+}
+
+func TestBuildSchema_EmbeddedConflicts(t *testing.T) {
+	s := internal.BuildSchema(ConflictingEmbeds{})
+	if !slices.Equal(s.PropertyOrdering, []string{"title"}) {
+		t.Errorf("❌ conflicting promoted fields must be dropped like encoding/json, got %v", s.PropertyOrdering)
+	}
+}
+
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func (*Money) GenAISchema() *genai.Schema {
+	return &genai.Schema{Type: genai.TypeString, Pattern: `^\d+(\.\d{2})? [A-Z]{3}$`, Description: "Amount and ISO 4217 currency, e.g. 12.50 USD."}
+}
+
+type CountryCode string
+
+func (CountryCode) GenAISchema() *genai.Schema {
+	return &genai.Schema{Type: genai.TypeString, Enum: []string{"EG", "SA", "AE"}}
+}
+
+// PhoneNumber stands in for a third-party type that cannot implement SchemaProvider.
+type PhoneNumber struct {
+	CountryCode int
+	National    string
+}
+
+type Offer struct {
+	Salary  Money         `json:"salary"`
+	Bonus   *Money        `json:"bonus" genai:"nullable,description=Optional signing bonus"`
+	Country CountryCode   `json:"country"`
+	Phone   PhoneNumber   `json:"phone"`
+	Phones  []PhoneNumber `json:"phones"`
+}
+
+func TestBuildSchema_SchemaProviders(t *testing.T) {
+	genaistructbuilder.RegisterSchema[PhoneNumber](&genai.Schema{Type: genai.TypeString, Format: "e164"})
+	s := internal.BuildSchema(Offer{})
+
+	if salary := s.Properties["salary"]; salary.Type != genai.TypeString || salary.Pattern == "" {
+		t.Errorf("❌ pointer-receiver SchemaProvider ignored, got %+v", salary)
+	}
+	bonus := s.Properties["bonus"]
+	if bonus.Type != genai.TypeString || bonus.Description != "Optional signing bonus" || bonus.Nullable == nil {
+		t.Errorf("❌ field tags should apply on top of a provided schema, got %+v", bonus)
+	}
+	if s.Properties["salary"].Description != "Amount and ISO 4217 currency, e.g. 12.50 USD." {
+		t.Errorf("❌ field tags must not leak into other uses of a provided schema")
+	}
+	if country := s.Properties["country"]; !slices.Equal(country.Enum, []string{"EG", "SA", "AE"}) {
+		t.Errorf("❌ value-receiver SchemaProvider ignored, got %+v", country)
+	}
+	if phone := s.Properties["phone"]; phone.Type != genai.TypeString || phone.Format != "e164" {
+		t.Errorf("❌ registered schema ignored, got %+v", phone)
+	}
+	if phones := s.Properties["phones"]; phones.Items.Format != "e164" {
+		t.Errorf("❌ registered schema ignored for slice items, got %+v", phones.Items)
+	}
+}
@@ -0,0 +1,222 @@
+package genaistructbuilder
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ExampleCandidate is the view of a few-shot example handed to an ExampleSelector.
+type ExampleCandidate struct {
+	Category string
+	Input    string
+}
+
+// ExampleSelector picks the examples worth sending for the current input. It
+// returns indexes into candidates; generators keep the selected examples in
+// their original order so categories stay grouped.
+type ExampleSelector interface {
+	SelectExamples(ctx context.Context, input string, candidates []ExampleCandidate) ([]int, error)
+}
+
+// ExampleSelectorFunc adapts a function to ExampleSelector.
+type ExampleSelectorFunc func(ctx context.Context, input string, candidates []ExampleCandidate) ([]int, error)
+
+func (f ExampleSelectorFunc) SelectExamples(ctx context.Context, input string, candidates []ExampleCandidate) ([]int, error) {
+	return f(ctx, input, candidates)
+}
+
+// topK returns the indexes of the k highest scores, ties broken by index.
+// k <= 0 keeps every index.
+func topK(scores []float64, k int) []int {
+	indexes := make([]int, len(scores))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool { return scores[indexes[a]] > scores[indexes[b]] })
+	if k > 0 && k < len(indexes) {
+		indexes = indexes[:k]
+	}
+	return indexes
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// BM25Selector ranks examples by Okapi BM25 lexical similarity between the
+// input and each example input. It runs fully offline.
+type BM25Selector struct {
+	// K is the number of examples to keep. Zero keeps all of them, ranked.
+	K int
+	// K1 and B are the BM25 parameters; zero values use 1.2 and 0.75.
+	K1 float64
+	B  float64
+}
+
+func (s BM25Selector) SelectExamples(ctx context.Context, input string, candidates []ExampleCandidate) ([]int, error) {
+	k1, b := s.K1, s.B
+	if k1 == 0 {
+		k1 = 1.2
+	}
+	if b == 0 {
+		b = 0.75
+	}
+	docs := make([]map[string]int, len(candidates))
+	lengths := make([]int, len(candidates))
+	documentFrequency := map[string]int{}
+	totalLength := 0
+	for i, candidate := range candidates {
+		terms := tokenize(candidate.Category + " " + candidate.Input)
+		docs[i] = map[string]int{}
+		for _, term := range terms {
+			if docs[i][term] == 0 {
+				documentFrequency[term]++
+			}
+			docs[i][term]++
+		}
+		lengths[i] = len(terms)
+		totalLength += len(terms)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	averageLength := float64(totalLength) / float64(len(candidates))
+	if averageLength == 0 {
+		averageLength = 1
+	}
+	query := map[string]bool{}
+	for _, term := range tokenize(input) {
+		query[term] = true
+	}
+	n := float64(len(candidates))
+	scores := make([]float64, len(candidates))
+	for term := range query {
+		df := float64(documentFrequency[term])
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for i, doc := range docs {
+			tf := float64(doc[term])
+			if tf == 0 {
+				continue
+			}
+			scores[i] += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(lengths[i])/averageLength))
+		}
+	}
+	return topK(scores, s.K), nil
+}
+
+// KeywordRouter routes the input to categories by keyword. Examples of every
+// category with a keyword found in the input are kept, in candidate order.
+type KeywordRouter struct {
+	// Routes maps a category name to the keywords that select it. Keywords
+	// match whole words, case-insensitively; multi-word keywords are allowed.
+	Routes map[string][]string
+	// K caps the number of examples kept. Zero keeps all matches.
+	K int
+	// Fallback selects examples when no category matches. Nil keeps the
+	// uncategorized examples.
+	Fallback ExampleSelector
+}
+
+func (r KeywordRouter) SelectExamples(ctx context.Context, input string, candidates []ExampleCandidate) ([]int, error) {
+	padded := " " + strings.Join(tokenize(input), " ") + " "
+	matched := map[string]bool{}
+	for category, keywords := range r.Routes {
+		for _, keyword := range keywords {
+			if words := tokenize(keyword); len(words) > 0 && strings.Contains(padded, " "+strings.Join(words, " ")+" ") {
+				matched[category] = true
+				break
+			}
+		}
+	}
+	if len(matched) == 0 && r.Fallback != nil {
+		return r.Fallback.SelectExamples(ctx, input, candidates)
+	}
+	var selected []int
+	for i, candidate := range candidates {
+		if matched[candidate.Category] || (len(matched) == 0 && candidate.Category == "") {
+			selected = append(selected, i)
+		}
+	}
+	if r.K > 0 && len(selected) > r.K {
+		selected = selected[:r.K]
+	}
+	return selected, nil
+}
+
+// EmbedFunc returns one embedding vector per text, e.g. backed by the
+// EmbedContent API of the genai client.
+type EmbedFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+// EmbeddingSelector ranks examples by cosine similarity between the embedding
+// of the input and the embedding of each example input. Example embeddings
+// are computed once and reused across calls.
+type EmbeddingSelector struct {
+	Embed EmbedFunc
+	// K is the number of examples to keep. Zero keeps all of them, ranked.
+	K int
+
+	mu    sync.Mutex
+	cache map[string][]float32
+}
+
+// NewEmbeddingSelector returns an EmbeddingSelector keeping the k most similar examples.
+func NewEmbeddingSelector(embed EmbedFunc, k int) *EmbeddingSelector {
+	return &EmbeddingSelector{Embed: embed, K: k}
+}
+
+func (s *EmbeddingSelector) SelectExamples(ctx context.Context, input string, candidates []ExampleCandidate) ([]int, error) {
+	s.mu.Lock()
+	if s.cache == nil {
+		s.cache = map[string][]float32{}
+	}
+	texts := []string{input}
+	for _, candidate := range candidates {
+		if _, ok := s.cache[candidate.Input]; !ok && !slices.Contains(texts[1:], candidate.Input) {
+			texts = append(texts, candidate.Input)
+		}
+	}
+	s.mu.Unlock()
+
+	vectors, err := s.Embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("embedding examples failed: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embedding examples failed: got %d vectors for %d texts", len(vectors), len(texts))
+	}
+
+	s.mu.Lock()
+	for i, text := range texts[1:] {
+		s.cache[text] = vectors[i+1]
+	}
+	scores := make([]float64, len(candidates))
+	for i, candidate := range candidates {
+		scores[i] = cosine(vectors[0], s.cache[candidate.Input])
+	}
+	s.mu.Unlock()
+	return topK(scores, s.K), nil
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range min(len(a), len(b)) {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
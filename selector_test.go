@@ -0,0 +1,148 @@
+package genaistructbuilder_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+)
+
+var selectorCandidates = []genaistructbuilder.ExampleCandidate{
+	{Input: "Senior Go backend developer in Cairo"},
+	{Input: "React frontend engineer, remote"},
+	{Category: "finance", Input: "Accountant for a fintech startup"},
+	{Category: "healthcare", Input: "Nurse for a private hospital"},
+	{Input: "Backend developer with Go and PostgreSQL"},
+}
+
+func TestBM25Selector_RanksRelevantExamples(t *testing.T) {
+	selector := genaistructbuilder.BM25Selector{K: 2}
+	got, err := selector.SelectExamples(context.Background(), "go backend developer", selectorCandidates)
+	if err != nil {
+		t.Fatalf("❌ SelectExamples failed: %v", err)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, []int{0, 4}) {
+		t.Errorf("❌ expected the two Go backend examples, got %v", got)
+	}
+}
+
+func TestKeywordRouter_RoutesAndFallsBack(t *testing.T) {
+	router := genaistructbuilder.KeywordRouter{Routes: map[string][]string{
+		"finance":    {"fintech", "bank"},
+		"healthcare": {"hospital", "clinic"},
+	}}
+	ctx := context.Background()
+
+	got, _ := router.SelectExamples(ctx, "Hiring for our Bank branch", selectorCandidates)
+	if !slices.Equal(got, []int{2}) {
+		t.Errorf("❌ expected the finance example, got %v", got)
+	}
+	got, _ = router.SelectExamples(ctx, "banking analysts", selectorCandidates)
+	if !slices.Equal(got, []int{0, 1, 4}) {
+		t.Errorf("❌ expected keywords to match whole words only and fall back to uncategorized examples, got %v", got)
+	}
+
+	router.Fallback = genaistructbuilder.BM25Selector{K: 1}
+	got, _ = router.SelectExamples(ctx, "react engineer", selectorCandidates)
+	if !slices.Equal(got, []int{1}) {
+		t.Errorf("❌ expected the fallback selector to pick the React example, got %v", got)
+	}
+}
+
+func TestEmbeddingSelector_CachesExampleEmbeddings(t *testing.T) {
+	var calls [][]string
+	embed := func(ctx context.Context, texts []string) ([][]float32, error) {
+		calls = append(calls, texts)
+		vectors := make([][]float32, len(texts))
+		for i, text := range texts {
+			lower := strings.ToLower(text)
+			vectors[i] = []float32{0.1, 0.1}
+			if strings.Contains(lower, "backend") {
+				vectors[i][0] = 1
+			}
+			if strings.Contains(lower, "react") {
+				vectors[i][1] = 1
+			}
+		}
+		return vectors, nil
+	}
+	selector := genaistructbuilder.NewEmbeddingSelector(embed, 1)
+
+	for _, tc := range []struct {
+		input string
+		want  int
+	}{{"backend role", 0}, {"react role", 1}} {
+		got, err := selector.SelectExamples(context.Background(), tc.input, selectorCandidates)
+		if err != nil {
+			t.Fatalf("❌ SelectExamples failed: %v", err)
+		}
+		if !slices.Equal(got, []int{tc.want}) {
+			t.Errorf("❌ %q: expected [%d], got %v", tc.input, tc.want, got)
+		}
+	}
+	if len(calls) != 2 || len(calls[0]) != len(selectorCandidates)+1 || len(calls[1]) != 1 {
+		t.Errorf("❌ expected example embeddings to be computed once, got calls %v", calls)
+	}
+
+	failing := genaistructbuilder.NewEmbeddingSelector(func(ctx context.Context, texts []string) ([][]float32, error) {
+		return nil, errors.New("quota exceeded")
+	}, 1)
+	if _, err := failing.SelectExamples(context.Background(), "x", selectorCandidates); err == nil {
+		t.Error("❌ expected the embed error to be returned")
+	}
+}
+
+func TestGenAiStructBuilder_ExampleSelector_AllRealGenerators(t *testing.T) {
+	keepFinance := genaistructbuilder.ExampleSelectorFunc(func(ctx context.Context, input string, candidates []genaistructbuilder.ExampleCandidate) ([]int, error) {
+		var selected []int
+		for i, candidate := range candidates {
+			if candidate.Category == "finance" || candidate.Category == "locations" {
+				selected = append(selected, i)
+			}
+		}
+		return selected, nil
+	})
+	wantByGenerator := map[string]string{
+		"PromptGenerator":       "Backend devs wanted in Riyadh",
+		"RelationGenerator":     `{"offer": "fintech-offer"}`,
+		"FileRelationGenerator": `{"offer": "fintech-offer"}`,
+	}
+	dropped := []string{"Looking for someone mid-level", "Candidates from FAANG", "backend-offer", "healthcare-offer"}
+
+	for _, tc := range newExampleGeneratorCases(genaistructbuilder.ExampleFormatTurns) {
+		t.Run(tc.Name, func(t *testing.T) {
+			switch g := tc.Generator.(type) {
+			case *generator.PromptGenerator[JobSearchOutput]:
+				g.ExampleSelector = keepFinance
+			case *generator.RelationGenerator[JobSearchOutput]:
+				g.ExampleSelector = keepFinance
+			case *generator.FileRelationGenerator[JobSearchOutput]:
+				g.ExampleSelector = keepFinance
+			}
+			mock := &RecordingMock{Outputs: []string{validMockJSON}}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, "gemini-test-mock", &output); err != nil {
+				t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+			}
+			sent := contentsText(mock.Contents()[0])
+			if !strings.Contains(sent, wantByGenerator[tc.Name]) {
+				t.Errorf("❌ %s: selected example missing from contents", tc.Name)
+			}
+			for _, text := range dropped {
+				if strings.Contains(sent, text) {
+					t.Errorf("❌ %s: unselected example %q was sent", tc.Name, text)
+				}
+			}
+			if len(mock.Contents()[0]) != 3 {
+				t.Errorf("❌ %s: expected one example turn pair plus the request, got %d contents", tc.Name, len(mock.Contents()[0]))
+			}
+		})
+	}
+}
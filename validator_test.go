@@ -0,0 +1,182 @@
+package genaistructbuilder_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/darwishdev/genaistructbuilder"
+	"github.com/darwishdev/genaistructbuilder/generator"
+	"github.com/darwishdev/genaistructbuilder/internal"
+	genai "google.golang.org/genai"
+)
+
+func int64Ptr(v int64) *int64       { return &v }
+func float64Ptr(v float64) *float64 { return &v }
+func boolPtr(v bool) *bool          { return &v }
+
+func TestValidateJSON(t *testing.T) {
+	schema := &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"title":    {Type: genai.TypeString, MinLength: int64Ptr(2)},
+			"level":    {Type: genai.TypeString, Enum: []string{"junior", "senior"}},
+			"years":    {Type: genai.TypeInteger, Minimum: float64Ptr(0), Maximum: float64Ptr(50)},
+			"score":    {Type: genai.TypeNumber},
+			"remote":   {Type: genai.TypeBoolean, Nullable: boolPtr(true)},
+			"start":    {Type: genai.TypeString, Format: "date-time"},
+			"skills":   {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeString}, MinItems: int64Ptr(1), MaxItems: int64Ptr(3)},
+			"a/b~c":    {Type: genai.TypeString},
+			"location": {Type: genai.TypeObject, Properties: map[string]*genai.Schema{"city": {Type: genai.TypeString}}, Required: []string{"city"}},
+		},
+		Required: []string{"title", "skills"},
+	}
+
+	tests := []struct {
+		Name  string
+		JSON  string
+		Paths []string
+	}{
+		{"valid", `{"title": "Go Dev", "level": "senior", "years": 5, "score": 4.5, "remote": null, "start": "2025-01-02T15:04:05Z", "skills": ["Go"], "location": {"city": "Cairo"}}`, nil},
+		{"missing_required", `{"skills": ["Go"]}`, []string{"/title"}},
+		{"wrong_types", `{"title": 3, "skills": "Go", "remote": "yes"}`, []string{"/remote", "/skills", "/title"}},
+		{"enum_and_range", `{"title": "Go Dev", "level": "lead", "years": 70, "skills": ["Go"]}`, []string{"/level", "/years"}},
+		{"integer_fraction", `{"title": "Go Dev", "years": 2.5, "skills": ["Go"]}`, []string{"/years"}},
+		{"items", `{"title": "Go Dev", "skills": ["Go", 1, "Rust", "C"]}`, []string{"/skills", "/skills/1"}},
+		{"nested_and_escaped", `{"title": "Go Dev", "skills": [], "location": {}, "a/b~c": false}`, []string{"/a~1b~0c", "/location/city", "/skills"}},
+		{"null_not_allowed", `{"title": null, "skills": ["Go"]}`, []string{"/title"}},
+		{"format_and_length", `{"title": "G", "start": "yesterday", "skills": ["Go"]}`, []string{"/start", "/title"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := internal.ValidateJSON(schema, []byte(tc.JSON))
+			if tc.Paths == nil {
+				if err != nil {
+					t.Fatalf("❌ expected no violations, got %v", err)
+				}
+				return
+			}
+			var validationErr *genaistructbuilder.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("❌ expected a ValidationError, got %v", err)
+			}
+			paths := map[string]bool{}
+			for _, v := range validationErr.Violations {
+				paths[v.Path] = true
+			}
+			for _, want := range tc.Paths {
+				if !paths[want] {
+					t.Errorf("❌ expected a violation at %s, got %v", want, validationErr)
+				}
+			}
+			if len(paths) != len(tc.Paths) {
+				t.Errorf("❌ expected violations at %v, got %v", tc.Paths, validationErr)
+			}
+		})
+	}
+}
+
+// withValidation returns a copy of a generator case with ValidateOutput enabled.
+func withValidation(t *testing.T, g genaistructbuilder.Generator[JobSearchOutput]) genaistructbuilder.Generator[JobSearchOutput] {
+	t.Helper()
+	switch g := g.(type) {
+	case *generator.PromptGenerator[JobSearchOutput]:
+		c := *g
+		c.ValidateOutput = true
+		return &c
+	case *generator.RelationGenerator[JobSearchOutput]:
+		c := *g
+		c.ValidateOutput = true
+		return &c
+	case *generator.FileRelationGenerator[JobSearchOutput]:
+		c := *g
+		c.ValidateOutput = true
+		return &c
+	}
+	t.Fatalf("❌ unsupported generator %T", g)
+	return nil
+}
+
+func TestGenAiStructBuilder_ValidateOutput_AllRealGenerators(t *testing.T) {
+	testModel := "gemini-test-mock"
+	// job_title is required by the test schema and skills must be an array of strings.
+	invalidJSON := `{"skills": ["Go", 7], "yearsof_experience_from": 3}`
+
+	for _, tc := range newGeneratorCases(t) {
+		t.Run(tc.Name+"/rejected", func(t *testing.T) {
+			mock := &RecordingMock{Outputs: []string{invalidJSON}}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+
+			var output JobSearchOutput
+			err := builder.Build(withValidation(t, tc.Generator), testModel, &output)
+			var validationErr *genaistructbuilder.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("❌ %s: expected a ValidationError, got %v", tc.Name, err)
+			}
+			if len(validationErr.Violations) != 2 {
+				t.Errorf("❌ %s: expected 2 violations, got %v", tc.Name, validationErr)
+			}
+		})
+
+		t.Run(tc.Name+"/disabled", func(t *testing.T) {
+			mock := &RecordingMock{Outputs: []string{`{"skills": ["Go"]}`}}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](mock.GenerateContent)
+
+			var output JobSearchOutput
+			if err := builder.Build(tc.Generator, testModel, &output); err != nil {
+				t.Fatalf("❌ %s: Build failed without validation: %v", tc.Name, err)
+			}
+		})
+
+		t.Run(tc.Name+"/repaired", func(t *testing.T) {
+			mock := &RecordingMock{Outputs: []string{invalidJSON, validMockJSON}}
+			builder := genaistructbuilder.NewStructBuilder[JobSearchOutput](
+				mock.GenerateContent,
+				genaistructbuilder.WithRepairAttempts(1),
+			)
+
+			var output JobSearchOutput
+			if err := builder.Build(withValidation(t, tc.Generator), testModel, &output); err != nil {
+				t.Fatalf("❌ %s: Build failed: %v", tc.Name, err)
+			}
+			CheckOutput(t, output, tc.Name)
+		})
+	}
+}
+
+func TestGenAiStructBuilder_ValidateOutput_RedactsViolationValues(t *testing.T) {
+	type level struct {
+		Name  string `json:"name"`
+		Level string `json:"level" genai:"enum=junior|senior"`
+	}
+	leaked := "John Doe SSN 123-45-6789"
+	raw := `{"name": "x", "level": "` + leaked + `"}`
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	mock := &RecordingMock{Outputs: []string{raw}}
+	builder := genaistructbuilder.NewStructBuilder[level](
+		mock.GenerateContent,
+		genaistructbuilder.WithLogger(logger),
+		genaistructbuilder.WithRedactedBodies(),
+	)
+
+	var output level
+	err := builder.Build(&generator.PromptGenerator[level]{Prompt: "x", ValidateOutput: true}, "gemini-test-mock", &output)
+	var validationErr *genaistructbuilder.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("❌ expected a ValidationError, got %v", err)
+	}
+	violation := validationErr.Violations[0]
+	if violation.Path != "/level" || violation.Rule != "enum" || violation.Value != leaked {
+		t.Errorf("❌ unexpected violation: %+v", violation)
+	}
+	if strings.Contains(err.Error(), leaked) || strings.Contains(buf.String(), leaked) {
+		t.Errorf("❌ violation value leaked into the error or logs:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "/level (enum)") {
+		t.Errorf("❌ expected the redacted log to keep the path and rule, got:\n%s", buf.String())
+	}
+}